@@ -0,0 +1,361 @@
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	cborUnsigned = iota
+	cborNegative
+	cborBytes
+	cborString
+	cborArray
+	cborMap
+	cborTag
+	cborSimple
+)
+
+// indefinite-length items end with this byte
+const cborBreak = 0xff
+
+// MarshalCBOR encodes v as CBOR. Times are encoded as RFC 3339 strings
+// under tag 0, floats in the shortest of single and double precision
+// that holds them exactly, and map keys in sorted order.
+func MarshalCBOR(v any) ([]byte, error) {
+	w := &cborWriter{}
+	if err := encode(w, reflect.ValueOf(v), 0); err != nil {
+		return nil, fmt.Errorf("cbor: %w", err)
+	}
+	return w.buf, nil
+}
+
+// UnmarshalCBOR decodes a single CBOR data item into the value v points
+// to. Indefinite-length items and half-precision floats are supported;
+// tags other than 0 and 1 (times) are dropped, keeping their content.
+func UnmarshalCBOR(data []byte, v any) error {
+	d := &cborDecoder{reader{data: data}}
+	value, err := d.decode(0)
+	if err == nil && d.remaining() > 0 {
+		err = errors.New("trailing data after top-level value")
+	}
+	if err == nil {
+		err = unmarshal(value, v)
+	}
+	if err != nil {
+		return fmt.Errorf("cbor: %w", err)
+	}
+	return nil
+}
+
+type cborWriter struct {
+	buf []byte
+}
+
+func (w *cborWriter) head(major byte, n uint64) {
+	switch {
+	case n < 24:
+		w.buf = append(w.buf, major<<5|byte(n))
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, major<<5|24, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, major<<5|25), uint16(n))
+	case n <= math.MaxUint32:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, major<<5|26), uint32(n))
+	default:
+		w.buf = binary.BigEndian.AppendUint64(append(w.buf, major<<5|27), n)
+	}
+}
+
+func (w *cborWriter) null() { w.buf = append(w.buf, 0xf6) }
+
+func (w *cborWriter) boolean(b bool) {
+	if b {
+		w.buf = append(w.buf, 0xf5)
+	} else {
+		w.buf = append(w.buf, 0xf4)
+	}
+}
+
+func (w *cborWriter) integer(i int64) {
+	if i >= 0 {
+		w.head(cborUnsigned, uint64(i))
+	} else {
+		w.head(cborNegative, uint64(-1-i))
+	}
+}
+
+func (w *cborWriter) unsigned(u uint64) { w.head(cborUnsigned, u) }
+
+func (w *cborWriter) float(f float64) {
+	if f32 := float32(f); float64(f32) == f {
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, 0xfa), math.Float32bits(f32))
+		return
+	}
+	w.buf = binary.BigEndian.AppendUint64(append(w.buf, 0xfb), math.Float64bits(f))
+}
+
+func (w *cborWriter) str(s string) {
+	w.head(cborString, uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *cborWriter) bytes(b []byte) {
+	w.head(cborBytes, uint64(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *cborWriter) array(n int)  { w.head(cborArray, uint64(n)) }
+func (w *cborWriter) object(n int) { w.head(cborMap, uint64(n)) }
+
+func (w *cborWriter) time(t time.Time) {
+	w.head(cborTag, 0)
+	w.str(t.Format(time.RFC3339Nano))
+}
+
+type cborDecoder struct {
+	reader
+}
+
+func (d *cborDecoder) decode(depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, errDepth
+	}
+	initial, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+	major, info := initial>>5, initial&0x1f
+
+	if major == cborSimple {
+		return d.simple(info)
+	}
+	if info == 31 {
+		return d.indefinite(major, depth)
+	}
+	n, err := d.argument(info)
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUnsigned:
+		if n > math.MaxInt64 {
+			return n, nil
+		}
+		return int64(n), nil
+	case cborNegative:
+		if n > math.MaxInt64 {
+			return nil, errors.New("negative integer overflows int64")
+		}
+		return -1 - int64(n), nil
+	case cborBytes:
+		b, err := d.next(n)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case cborString:
+		b, err := d.next(n)
+		if err != nil {
+			return nil, err
+		}
+		if !utf8.Valid(b) {
+			return nil, errors.New("invalid UTF-8 in text string")
+		}
+		return string(b), nil
+	case cborArray:
+		// every item takes at least a byte, which bounds the allocation
+		if n > uint64(d.remaining()) {
+			return nil, errors.New("unexpected end of input")
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = d.decode(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	case cborMap:
+		if n > uint64(d.remaining())/2 {
+			return nil, errors.New("unexpected end of input")
+		}
+		data := make(map[string]any, n)
+		for range n {
+			if err := d.pair(data, depth); err != nil {
+				return nil, err
+			}
+		}
+		return data, nil
+	default:
+		return d.tag(n, depth)
+	}
+}
+
+func (d *cborDecoder) argument(info byte) (uint64, error) {
+	switch {
+	case info < 24:
+		return uint64(info), nil
+	case info <= 27:
+		return d.uint(1 << (info - 24))
+	default:
+		return 0, fmt.Errorf("invalid additional information %d", info)
+	}
+}
+
+func (d *cborDecoder) simple(info byte) (any, error) {
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		// null and undefined
+		return nil, nil
+	case 25:
+		u, err := d.uint(2)
+		return half(uint16(u)), err
+	case 26:
+		u, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 27:
+		u, err := d.uint(8)
+		return math.Float64frombits(u), err
+	case 31:
+		return nil, errors.New("unexpected break")
+	default:
+		return nil, fmt.Errorf("unsupported simple value %d", info)
+	}
+}
+
+func (d *cborDecoder) indefinite(major byte, depth int) (any, error) {
+	switch major {
+	case cborBytes, cborString:
+		var buf []byte
+		for !d.atBreak() {
+			initial, err := d.byte()
+			if err != nil {
+				return nil, err
+			}
+			if initial>>5 != major || initial&0x1f == 31 {
+				return nil, errors.New("invalid chunk in indefinite-length string")
+			}
+			n, err := d.argument(initial & 0x1f)
+			if err != nil {
+				return nil, err
+			}
+			chunk, err := d.next(n)
+			if err != nil {
+				return nil, err
+			}
+			buf = append(buf, chunk...)
+		}
+		if major == cborBytes {
+			return buf, nil
+		}
+		if !utf8.Valid(buf) {
+			return nil, errors.New("invalid UTF-8 in text string")
+		}
+		return string(buf), nil
+	case cborArray:
+		items := []any{}
+		for !d.atBreak() {
+			item, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case cborMap:
+		data := map[string]any{}
+		for !d.atBreak() {
+			if err := d.pair(data, depth); err != nil {
+				return nil, err
+			}
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("major type %d cannot have indefinite length", major)
+	}
+}
+
+// atBreak consumes a break byte if one is next. At the end of input it
+// reports false, so that decoding the next item fails.
+func (d *cborDecoder) atBreak() bool {
+	if d.remaining() > 0 && d.data[d.pos] == cborBreak {
+		d.pos++
+		return true
+	}
+	return false
+}
+
+func (d *cborDecoder) pair(data map[string]any, depth int) error {
+	k, err := d.decode(depth + 1)
+	if err != nil {
+		return err
+	}
+	name, err := key(k)
+	if err != nil {
+		return err
+	}
+	if data[name], err = d.decode(depth + 1); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (d *cborDecoder) tag(n uint64, depth int) (any, error) {
+	value, err := d.decode(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+	switch n {
+	case 0:
+		s, ok := value.(string)
+		if !ok {
+			return nil, errors.New("tag 0 expects a text string")
+		}
+		return time.Parse(time.RFC3339Nano, s)
+	case 1:
+		switch epoch := value.(type) {
+		case int64:
+			return time.Unix(epoch, 0).UTC(), nil
+		case float64:
+			sec, frac := math.Modf(epoch)
+			return time.Unix(int64(sec), int64(frac*1e9)).UTC(), nil
+		default:
+			return nil, errors.New("tag 1 expects a number")
+		}
+	default:
+		return value, nil
+	}
+}
+
+// half converts an IEEE 754 half-precision float.
+func half(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
@@ -0,0 +1,478 @@
+// Package codec encodes and decodes the binary formats CBOR (RFC 8949)
+// and MessagePack. Struct fields are named by their json tag, so the same
+// types serve JSON, CBOR and MessagePack bodies alike.
+//
+// Decoding into an interface yields nil, bool, int64 (uint64 for larger
+// values), float64, string, []byte, time.Time, []any and map[string]any.
+package codec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// nesting beyond this is rejected rather than risk exhausting the stack
+const maxDepth = 1000
+
+var (
+	timeType   = reflect.TypeFor[time.Time]()
+	numberType = reflect.TypeFor[json.Number]()
+	errDepth   = errors.New("maximum nesting depth exceeded")
+)
+
+// writer is implemented by each format's encoder.
+type writer interface {
+	null()
+	boolean(b bool)
+	integer(i int64)
+	unsigned(u uint64)
+	float(f float64)
+	str(s string)
+	bytes(b []byte)
+	array(n int)
+	object(n int)
+	time(t time.Time)
+}
+
+func encode(w writer, v reflect.Value, depth int) error {
+	if depth > maxDepth {
+		return errDepth
+	}
+	if !v.IsValid() {
+		w.null()
+		return nil
+	}
+	switch v.Type() {
+	case timeType:
+		w.time(v.Interface().(time.Time))
+		return nil
+	case numberType:
+		n := json.Number(v.String())
+		if i, err := n.Int64(); err == nil {
+			w.integer(i)
+			return nil
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return err
+		}
+		w.float(f)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			w.null()
+			return nil
+		}
+		return encode(w, v.Elem(), depth+1)
+	case reflect.Bool:
+		w.boolean(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		w.integer(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		w.unsigned(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		w.float(v.Float())
+	case reflect.String:
+		w.str(v.String())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			w.null()
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			w.bytes(b)
+			return nil
+		}
+		w.array(v.Len())
+		for i := range v.Len() {
+			if err := encode(w, v.Index(i), depth+1); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			w.null()
+			return nil
+		}
+		// sorted for deterministic output
+		keys := v.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return strings.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+		})
+		w.object(len(keys))
+		for _, key := range keys {
+			switch key.Kind() {
+			case reflect.String:
+				w.str(key.String())
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				w.integer(key.Int())
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+				w.unsigned(key.Uint())
+			default:
+				return fmt.Errorf("unsupported map key type %s", key.Type())
+			}
+			if err := encode(w, v.MapIndex(key), depth+1); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		type present struct {
+			name  string
+			value reflect.Value
+		}
+		var values []present
+		for _, f := range structFields(v.Type()) {
+			fv, err := v.FieldByIndexErr(f.index)
+			if err != nil || f.omitEmpty && isEmpty(fv) {
+				// behind a nil embedded pointer
+				continue
+			}
+			values = append(values, present{f.name, fv})
+		}
+		w.object(len(values))
+		for _, p := range values {
+			w.str(p.name)
+			if err := encode(w, p.value, depth+1); err != nil {
+				return fmt.Errorf("%s: %w", p.name, err)
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+type structField struct {
+	index     []int
+	name      string
+	omitEmpty bool
+}
+
+func structFields(t reflect.Type) []structField {
+	var fields []structField
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous && f.Type.Kind() == reflect.Struct {
+			continue
+		}
+		name, options, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" && options == "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, structField{
+			index:     f.Index,
+			name:      name,
+			omitEmpty: slices.Contains(strings.Split(options, ","), "omitempty"),
+		})
+	}
+	return fields
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	default:
+		return false
+	}
+}
+
+// unmarshal stores a decoded value in the value v points to.
+func unmarshal(value any, v any) error {
+	dst := reflect.ValueOf(v)
+	if dst.Kind() != reflect.Pointer || dst.IsNil() {
+		return fmt.Errorf("expected non-nil pointer, got %T", v)
+	}
+	return assign(dst.Elem(), value)
+}
+
+func assign(dst reflect.Value, src any) error {
+	switch {
+	case dst.Kind() == reflect.Pointer:
+		if src == nil {
+			dst.SetZero()
+			return nil
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return assign(dst.Elem(), src)
+	case dst.Kind() == reflect.Interface && dst.NumMethod() == 0:
+		if src == nil {
+			dst.SetZero()
+		} else {
+			dst.Set(reflect.ValueOf(src))
+		}
+		return nil
+	case src == nil:
+		dst.SetZero()
+		return nil
+	}
+
+	v := reflect.ValueOf(src)
+	switch dst.Kind() {
+	case reflect.Bool:
+		if b, ok := src.(bool); ok {
+			dst.SetBool(b)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, ok := toInt(src); ok && !dst.OverflowInt(i) {
+			dst.SetInt(i)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if u, ok := toUint(src); ok && !dst.OverflowUint(u) {
+			dst.SetUint(u)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, ok := toFloat(src); ok {
+			dst.SetFloat(f)
+			return nil
+		}
+	case reflect.String:
+		switch s := src.(type) {
+		case string:
+			dst.SetString(s)
+			return nil
+		case int64, uint64, float64:
+			if dst.Type() == numberType {
+				dst.SetString(fmt.Sprint(s))
+				return nil
+			}
+		}
+	case reflect.Slice:
+		if dst.Type().Elem().Kind() == reflect.Uint8 {
+			if b, ok := src.([]byte); ok {
+				dst.SetBytes(b)
+				return nil
+			}
+		}
+		items, ok := src.([]any)
+		if !ok {
+			break
+		}
+		slice := reflect.MakeSlice(dst.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := assign(slice.Index(i), item); err != nil {
+				return fmt.Errorf("%d: %w", i, err)
+			}
+		}
+		dst.Set(slice)
+		return nil
+	case reflect.Array:
+		if b, ok := src.([]byte); ok && dst.Type().Elem().Kind() == reflect.Uint8 && len(b) == dst.Len() {
+			reflect.Copy(dst, v)
+			return nil
+		}
+		items, ok := src.([]any)
+		if !ok || len(items) != dst.Len() {
+			break
+		}
+		for i, item := range items {
+			if err := assign(dst.Index(i), item); err != nil {
+				return fmt.Errorf("%d: %w", i, err)
+			}
+		}
+		return nil
+	case reflect.Map:
+		data, ok := src.(map[string]any)
+		if !ok {
+			break
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMapWithSize(dst.Type(), len(data)))
+		}
+		keyType, elemType := dst.Type().Key(), dst.Type().Elem()
+		for name, item := range data {
+			key := reflect.New(keyType).Elem()
+			if err := assignKey(key, name); err != nil {
+				return err
+			}
+			elem := reflect.New(elemType).Elem()
+			if err := assign(elem, item); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			dst.SetMapIndex(key, elem)
+		}
+		return nil
+	case reflect.Struct:
+		if dst.Type() == timeType {
+			if _, ok := src.(time.Time); ok {
+				dst.Set(v)
+				return nil
+			} else if s, ok := src.(string); ok {
+				if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+					dst.Set(reflect.ValueOf(t))
+					return nil
+				}
+			}
+			break
+		}
+		data, ok := src.(map[string]any)
+		if !ok {
+			break
+		}
+		for _, f := range structFields(dst.Type()) {
+			item, ok := data[f.name]
+			if !ok {
+				continue
+			}
+			field, err := fieldByIndex(dst, f.index)
+			if err != nil {
+				return err
+			}
+			if err := assign(field, item); err != nil {
+				return fmt.Errorf("%s: %w", f.name, err)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("cannot assign %T to %s", src, dst.Type())
+}
+
+func assignKey(key reflect.Value, name string) error {
+	switch key.Kind() {
+	case reflect.String:
+		key.SetString(name)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(name, 10, 64)
+		if err == nil && !key.OverflowInt(i) {
+			key.SetInt(i)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(name, 10, 64)
+		if err == nil && !key.OverflowUint(u) {
+			key.SetUint(u)
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot use key %q as %s", name, key.Type())
+}
+
+// fieldByIndex is FieldByIndex allocating nil embedded pointers on the way.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, fmt.Errorf("cannot set embedded pointer to unexported %s", v.Type().Elem())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+func toInt(src any) (int64, bool) {
+	switch n := src.(type) {
+	case int64:
+		return n, true
+	case uint64:
+		return int64(n), n <= math.MaxInt64
+	case float64:
+		return int64(n), n == math.Trunc(n) && n >= -0x1p63 && n < 0x1p63
+	default:
+		return 0, false
+	}
+}
+
+func toUint(src any) (uint64, bool) {
+	switch n := src.(type) {
+	case int64:
+		return uint64(n), n >= 0
+	case uint64:
+		return n, true
+	case float64:
+		return uint64(n), n == math.Trunc(n) && n >= 0 && n < 0x1p64
+	default:
+		return 0, false
+	}
+}
+
+func toFloat(src any) (float64, bool) {
+	switch n := src.(type) {
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+// key renders a decoded map key; non-string keys use their text form.
+func key(k any) (string, error) {
+	switch k := k.(type) {
+	case string:
+		return k, nil
+	case int64, uint64, float64, bool:
+		return fmt.Sprint(k), nil
+	default:
+		return "", fmt.Errorf("unsupported map key of type %T", k)
+	}
+}
+
+// reader tracks the position in the input common to both decoders.
+type reader struct {
+	data []byte
+	pos  int
+}
+
+func (r *reader) remaining() int { return len(r.data) - r.pos }
+
+func (r *reader) byte() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, errors.New("unexpected end of input")
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *reader) next(n uint64) ([]byte, error) {
+	if n > uint64(r.remaining()) {
+		return nil, errors.New("unexpected end of input")
+	}
+	b := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+func (r *reader) uint(size int) (uint64, error) {
+	b, err := r.next(uint64(size))
+	if err != nil {
+		return 0, err
+	}
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+	return u, nil
+}
@@ -0,0 +1,358 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type inner struct {
+	X []int `json:"x"`
+}
+
+type record struct {
+	ID      int               `json:"id"`
+	Name    string            `json:"name,omitempty"`
+	Skipped string            `json:"-"`
+	Blob    []byte            `json:"blob"`
+	When    time.Time         `json:"when"`
+	Float   float64           `json:"float"`
+	Neg     int64             `json:"neg"`
+	Big     uint64            `json:"big"`
+	Map     map[string]string `json:"map"`
+	Ptr     *inner            `json:"ptr"`
+	Nil     *inner            `json:"nil"`
+	Small   int8              `json:"small"`
+	inner
+}
+
+type format struct {
+	name      string
+	marshal   func(any) ([]byte, error)
+	unmarshal func([]byte, any) error
+}
+
+var formats = []format{
+	{"cbor", MarshalCBOR, UnmarshalCBOR},
+	{"msgpack", MarshalMsgPack, UnmarshalMsgPack},
+}
+
+func sample() record {
+	return record{
+		ID:    7,
+		Blob:  []byte{1, 2, 3},
+		When:  time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC),
+		Float: 1.1,
+		Neg:   -70000,
+		Big:   math.MaxUint64,
+		Map:   map[string]string{"b": "2", "a": "1"},
+		Ptr:   &inner{X: []int{1}},
+		Small: -5,
+		inner: inner{X: []int{9, 8}},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, f := range formats {
+		t.Run(f.name, func(t *testing.T) {
+			in := sample()
+			data, err := f.marshal(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out record
+			if err := f.unmarshal(data, &out); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(in, out) {
+				t.Errorf("got %+v, want %+v", out, in)
+			}
+		})
+	}
+}
+
+func TestRoundTripValues(t *testing.T) {
+	values := []any{
+		nil, true, false,
+		int64(0), int64(23), int64(24), int64(-1), int64(-24), int64(-25), int64(-33),
+		int64(math.MaxInt8), int64(math.MaxUint8), int64(math.MaxUint16), int64(math.MaxUint32),
+		int64(math.MinInt8), int64(math.MinInt16), int64(math.MinInt32), int64(math.MinInt64),
+		int64(math.MaxInt64), uint64(math.MaxUint64),
+		0.5, 1.1, math.Inf(-1), math.MaxFloat64,
+		"", "héllo", strings.Repeat("x", 300), strings.Repeat("y", 70000),
+		[]byte{0}, bytes.Repeat([]byte{7}, 300),
+		[]any{}, []any{int64(1), "a", nil}, make([]any, 20),
+		map[string]any{}, map[string]any{"a": int64(1), "b": []any{true}},
+		time.Unix(1, 0).UTC(), time.Unix(1<<33, 5).UTC(), time.Unix(-1, 0).UTC(),
+	}
+	for _, f := range formats {
+		for _, in := range values {
+			data, err := f.marshal(in)
+			if err != nil {
+				t.Fatalf("%s: marshal %#v: %v", f.name, in, err)
+			}
+			var out any
+			if err := f.unmarshal(data, &out); err != nil {
+				t.Fatalf("%s: unmarshal %#v: %v", f.name, in, err)
+			}
+			if in, ok := in.(time.Time); ok {
+				if got, ok := out.(time.Time); !ok || !got.Equal(in) {
+					t.Errorf("%s: got %#v, want %v", f.name, out, in)
+				}
+				continue
+			}
+			if !reflect.DeepEqual(in, out) {
+				t.Errorf("%s: got %#v, want %#v", f.name, out, in)
+			}
+		}
+	}
+}
+
+func TestCBORVectors(t *testing.T) {
+	// from RFC 8949, appendix A
+	tests := []struct {
+		hex  string
+		want any
+	}{
+		{"00", int64(0)},
+		{"1903e8", int64(1000)},
+		{"1bffffffffffffffff", uint64(math.MaxUint64)},
+		{"3903e7", int64(-1000)},
+		{"f90000", 0.0},
+		{"f93c00", 1.0},
+		{"f97bff", 65504.0},
+		{"f90001", 5.960464477539063e-8},
+		{"f97c00", math.Inf(1)},
+		{"fa47c35000", 100000.0},
+		{"fb3ff199999999999a", 1.1},
+		{"f4", false},
+		{"f6", nil},
+		{"f7", nil},
+		{"4401020304", []byte{1, 2, 3, 4}},
+		{"62c3bc", "ü"},
+		{"83010203", []any{int64(1), int64(2), int64(3)}},
+		{"a26161016162820203", map[string]any{"a": int64(1), "b": []any{int64(2), int64(3)}}},
+		{"a201020304", map[string]any{"1": int64(2), "3": int64(4)}},
+		{"5f42010243030405ff", []byte{1, 2, 3, 4, 5}},
+		{"7f657374726561646d696e67ff", "streaming"},
+		{"9f018202039f0405ffff", []any{int64(1), []any{int64(2), int64(3)}, []any{int64(4), int64(5)}}},
+		{"bf61610161629f0203ffff", map[string]any{"a": int64(1), "b": []any{int64(2), int64(3)}}},
+		{"c074323031332d30332d32315432303a30343a30305a", time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC)},
+		{"c11a514b67b0", time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC)},
+		{"c1fb41d452d9ec200000", time.Date(2013, 3, 21, 20, 4, 0, 500000000, time.UTC)},
+		{"d74401020304", []byte{1, 2, 3, 4}},
+	}
+	for _, test := range tests {
+		data, _ := hex.DecodeString(test.hex)
+		var got any
+		if err := UnmarshalCBOR(data, &got); err != nil {
+			t.Errorf("%s: %v", test.hex, err)
+			continue
+		}
+		if want, ok := test.want.(time.Time); ok {
+			if tm, ok := got.(time.Time); !ok || !tm.Equal(want) {
+				t.Errorf("%s: got %v, want %v", test.hex, got, want)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %#v, want %#v", test.hex, got, test.want)
+		}
+	}
+}
+
+func TestMsgPackVectors(t *testing.T) {
+	tests := []struct {
+		hex  string
+		want any
+	}{
+		{"7f", int64(127)},
+		{"ff", int64(-1)},
+		{"e0", int64(-32)},
+		{"cc80", int64(128)},
+		{"d0df", int64(-33)},
+		{"d1fc18", int64(-1000)},
+		{"cfffffffffffffffff", uint64(math.MaxUint64)},
+		{"ca3f800000", 1.0},
+		{"c0", nil},
+		{"c3", true},
+		{"a3616263", "abc"},
+		{"d90568656c6c6f", "hello"},
+		{"c4020102", []byte{1, 2}},
+		{"92c0c3", []any{nil, true}},
+		{"81a16101", map[string]any{"a": int64(1)}},
+		{"d6ff00000001", time.Unix(1, 0).UTC()},
+	}
+	for _, test := range tests {
+		data, _ := hex.DecodeString(test.hex)
+		var got any
+		if err := UnmarshalMsgPack(data, &got); err != nil {
+			t.Errorf("%s: %v", test.hex, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %#v, want %#v", test.hex, got, test.want)
+		}
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	for _, f := range formats {
+		data, err := f.marshal(sample())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for n := range len(data) {
+			var out any
+			if err := f.unmarshal(data[:n], &out); err == nil {
+				t.Errorf("%s: prefix of %d bytes decoded without error", f.name, n)
+			}
+		}
+	}
+}
+
+func TestTrailingData(t *testing.T) {
+	for _, f := range formats {
+		data, _ := f.marshal(int64(1))
+		var out any
+		if err := f.unmarshal(append(data, 0), &out); err == nil {
+			t.Errorf("%s: trailing byte accepted", f.name)
+		}
+	}
+}
+
+func TestDepth(t *testing.T) {
+	tests := []struct {
+		name   string
+		format format
+		array  byte
+		leaf   byte
+	}{
+		{"cbor", formats[0], 0x81, 0x00},
+		{"msgpack", formats[1], 0x91, 0x00},
+	}
+	for _, test := range tests {
+		deep := append(bytes.Repeat([]byte{test.array}, maxDepth+1), test.leaf)
+		var out any
+		if err := test.format.unmarshal(deep, &out); !errors.Is(err, errDepth) {
+			t.Errorf("%s: got %v, want %v", test.name, err, errDepth)
+		}
+		shallow := append(bytes.Repeat([]byte{test.array}, maxDepth), test.leaf)
+		if err := test.format.unmarshal(shallow, &out); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+	}
+
+	var nested any = int64(0)
+	for range maxDepth + 1 {
+		nested = []any{nested}
+	}
+	for _, f := range formats {
+		if _, err := f.marshal(nested); !errors.Is(err, errDepth) {
+			t.Errorf("%s: marshal got %v, want %v", f.name, err, errDepth)
+		}
+	}
+}
+
+func TestOversizedLengths(t *testing.T) {
+	tests := []struct {
+		format format
+		hex    string
+	}{
+		{formats[0], "5bffffffffffffffff"}, // bytes
+		{formats[0], "7bffffffffffffffff"}, // text
+		{formats[0], "9bffffffffffffffff"}, // array
+		{formats[0], "bbffffffffffffffff"}, // map
+		{formats[0], "9a7fffffff00"},
+		{formats[0], "5f5bffffffffffffffffff"}, // indefinite bytes chunk
+		{formats[1], "c6ffffffff"},             // bin32
+		{formats[1], "dbffffffff"},             // str32
+		{formats[1], "ddffffffff"},             // array32
+		{formats[1], "dfffffffff"},             // map32
+		{formats[1], "c9ffffffffff"},           // ext32
+	}
+	for _, test := range tests {
+		data, _ := hex.DecodeString(test.hex)
+		var out any
+		if err := test.format.unmarshal(data, &out); err == nil {
+			t.Errorf("%s %s: decoded without error", test.format.name, test.hex)
+		}
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	tests := []struct {
+		format format
+		hex    string
+	}{
+		{formats[0], "1c"},                 // reserved additional information
+		{formats[0], "ff"},                 // lone break
+		{formats[0], "3bffffffffffffffff"}, // negative overflow
+		{formats[0], "62c328"},             // invalid UTF-8
+		{formats[0], "5f6161ff"},           // text chunk in byte string
+		{formats[0], "a1f6f6"},             // nil map key
+		{formats[0], "c06161"},             // tag 0 with a bad date
+		{formats[1], "c1"},                 // never used
+		{formats[1], "d40100"},             // unknown extension
+		{formats[1], "d5ff0000"},           // timestamp of 2 bytes
+	}
+	for _, test := range tests {
+		data, _ := hex.DecodeString(test.hex)
+		var out any
+		if err := test.format.unmarshal(data, &out); err == nil {
+			t.Errorf("%s %s: decoded without error", test.format.name, test.hex)
+		}
+	}
+}
+
+func TestAssign(t *testing.T) {
+	for _, f := range formats {
+		data, _ := f.marshal(map[string]any{"id": 300})
+		var small struct {
+			ID int8 `json:"id"`
+		}
+		if err := f.unmarshal(data, &small); err == nil {
+			t.Errorf("%s: 300 assigned to int8", f.name)
+		}
+		var out struct {
+			ID uint16 `json:"id"`
+		}
+		if err := f.unmarshal(data, &out); err != nil || out.ID != 300 {
+			t.Errorf("%s: got %d, %v", f.name, out.ID, err)
+		}
+		if err := f.unmarshal(data, out); err == nil {
+			t.Errorf("%s: non-pointer accepted", f.name)
+		}
+	}
+}
+
+func fuzz(f *testing.F, format format) {
+	for _, seed := range []any{sample(), map[string]any{"a": []any{int64(1), "b", nil}}, 1.5, "x"} {
+		data, _ := format.marshal(seed)
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var value any
+		if err := format.unmarshal(data, &value); err != nil {
+			return
+		}
+		encoded, err := format.marshal(value)
+		if err != nil {
+			t.Fatalf("re-encoding %#v: %v", value, err)
+		}
+		var again any
+		if err := format.unmarshal(encoded, &again); err != nil {
+			t.Fatalf("decoding re-encoded %x: %v", encoded, err)
+		}
+		var typed record
+		format.unmarshal(data, &typed)
+	})
+}
+
+func FuzzCBOR(f *testing.F)    { fuzz(f, formats[0]) }
+func FuzzMsgPack(f *testing.F) { fuzz(f, formats[1]) }
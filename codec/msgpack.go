@@ -0,0 +1,312 @@
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+// extension type of MessagePack timestamps
+const msgpackTimestamp = -1
+
+// MarshalMsgPack encodes v as MessagePack, using the smallest encoding
+// for each value. Times use the timestamp extension.
+func MarshalMsgPack(v any) ([]byte, error) {
+	w := &msgpackWriter{}
+	err := encode(w, reflect.ValueOf(v), 0)
+	if err == nil {
+		err = w.err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("msgpack: %w", err)
+	}
+	return w.buf, nil
+}
+
+// UnmarshalMsgPack decodes a single MessagePack value into the value v
+// points to. Extension types other than timestamps are rejected.
+func UnmarshalMsgPack(data []byte, v any) error {
+	d := &msgpackDecoder{reader{data: data}}
+	value, err := d.decode(0)
+	if err == nil && d.remaining() > 0 {
+		err = errors.New("trailing data after top-level value")
+	}
+	if err == nil {
+		err = unmarshal(value, v)
+	}
+	if err != nil {
+		return fmt.Errorf("msgpack: %w", err)
+	}
+	return nil
+}
+
+type msgpackWriter struct {
+	buf []byte
+	err error
+}
+
+// sized writes the header of a string, binary, array or map of length n:
+// the fix form if there is one and n fits, else the smallest of the 8, 16
+// and 32-bit forms in codes, where a zero code marks a missing form.
+func (w *msgpackWriter) sized(n int, fixed byte, fixMax int, codes [3]byte) {
+	switch {
+	case fixed != 0 && n <= fixMax:
+		w.buf = append(w.buf, fixed|byte(n))
+	case codes[0] != 0 && n <= math.MaxUint8:
+		w.buf = append(w.buf, codes[0], byte(n))
+	case n <= math.MaxUint16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, codes[1]), uint16(n))
+	case uint64(n) <= math.MaxUint32:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, codes[2]), uint32(n))
+	default:
+		w.err = fmt.Errorf("length %d exceeds the format's limit", n)
+	}
+}
+
+func (w *msgpackWriter) null() { w.buf = append(w.buf, 0xc0) }
+
+func (w *msgpackWriter) boolean(b bool) {
+	if b {
+		w.buf = append(w.buf, 0xc3)
+	} else {
+		w.buf = append(w.buf, 0xc2)
+	}
+}
+
+func (w *msgpackWriter) integer(i int64) {
+	switch {
+	case i >= 0:
+		w.unsigned(uint64(i))
+	case i >= -32:
+		w.buf = append(w.buf, byte(i))
+	case i >= math.MinInt8:
+		w.buf = append(w.buf, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, 0xd1), uint16(i))
+	case i >= math.MinInt32:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, 0xd2), uint32(i))
+	default:
+		w.buf = binary.BigEndian.AppendUint64(append(w.buf, 0xd3), uint64(i))
+	}
+}
+
+func (w *msgpackWriter) unsigned(u uint64) {
+	switch {
+	case u <= math.MaxInt8:
+		w.buf = append(w.buf, byte(u))
+	case u <= math.MaxUint8:
+		w.buf = append(w.buf, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, 0xcd), uint16(u))
+	case u <= math.MaxUint32:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, 0xce), uint32(u))
+	default:
+		w.buf = binary.BigEndian.AppendUint64(append(w.buf, 0xcf), u)
+	}
+}
+
+func (w *msgpackWriter) float(f float64) {
+	if f32 := float32(f); float64(f32) == f {
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, 0xca), math.Float32bits(f32))
+		return
+	}
+	w.buf = binary.BigEndian.AppendUint64(append(w.buf, 0xcb), math.Float64bits(f))
+}
+
+func (w *msgpackWriter) str(s string) {
+	w.sized(len(s), 0xa0, 31, [3]byte{0xd9, 0xda, 0xdb})
+	w.buf = append(w.buf, s...)
+}
+
+func (w *msgpackWriter) bytes(b []byte) {
+	w.sized(len(b), 0, 0, [3]byte{0xc4, 0xc5, 0xc6})
+	w.buf = append(w.buf, b...)
+}
+
+func (w *msgpackWriter) array(n int)  { w.sized(n, 0x90, 15, [3]byte{0, 0xdc, 0xdd}) }
+func (w *msgpackWriter) object(n int) { w.sized(n, 0x80, 15, [3]byte{0, 0xde, 0xdf}) }
+
+func (w *msgpackWriter) time(t time.Time) {
+	sec, nsec := t.Unix(), uint64(t.Nanosecond())
+	switch {
+	case sec >= 0 && sec <= math.MaxUint32 && nsec == 0:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, 0xd6, 0xff), uint32(sec))
+	case sec >= 0 && sec < 1<<34:
+		w.buf = binary.BigEndian.AppendUint64(append(w.buf, 0xd7, 0xff), nsec<<34|uint64(sec))
+	default:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, 0xc7, 12, 0xff), uint32(nsec))
+		w.buf = binary.BigEndian.AppendUint64(w.buf, uint64(sec))
+	}
+}
+
+type msgpackDecoder struct {
+	reader
+}
+
+func (d *msgpackDecoder) decode(depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, errDepth
+	}
+	code, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case code <= 0x7f:
+		return int64(code), nil
+	case code >= 0xe0:
+		return int64(int8(code)), nil
+	case code&0xf0 == 0x80:
+		return d.object(uint64(code&0x0f), depth)
+	case code&0xf0 == 0x90:
+		return d.array(uint64(code&0x0f), depth)
+	case code&0xe0 == 0xa0:
+		return d.str(uint64(code & 0x1f))
+	}
+
+	switch code {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.uint(1 << (code - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.next(n)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.uint(1 << (code - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.ext(n)
+	case 0xca:
+		u, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := d.uint(8)
+		return math.Float64frombits(u), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := d.uint(1 << (code - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if u > math.MaxInt64 {
+			return u, nil
+		}
+		return int64(u), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (code - 0xd0)
+		u, err := d.uint(size)
+		if err != nil {
+			return nil, err
+		}
+		// sign-extend from the encoded width
+		shift := 64 - 8*size
+		return int64(u<<shift) >> shift, nil
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.ext(1 << (code - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.uint(1 << (code - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(n)
+	case 0xdc, 0xdd:
+		n, err := d.uint(2 << (code - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.array(n, depth)
+	case 0xde, 0xdf:
+		n, err := d.uint(2 << (code - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.object(n, depth)
+	default:
+		return nil, fmt.Errorf("invalid code 0x%02x", code)
+	}
+}
+
+func (d *msgpackDecoder) str(n uint64) (any, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (d *msgpackDecoder) array(n uint64, depth int) (any, error) {
+	// every item takes at least a byte, which bounds the allocation
+	if n > uint64(d.remaining()) {
+		return nil, errors.New("unexpected end of input")
+	}
+	items := make([]any, n)
+	for i := range items {
+		var err error
+		if items[i], err = d.decode(depth + 1); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+func (d *msgpackDecoder) object(n uint64, depth int) (any, error) {
+	if n > uint64(d.remaining())/2 {
+		return nil, errors.New("unexpected end of input")
+	}
+	data := make(map[string]any, n)
+	for range n {
+		k, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		name, err := key(k)
+		if err != nil {
+			return nil, err
+		}
+		if data[name], err = d.decode(depth + 1); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func (d *msgpackDecoder) ext(n uint64) (any, error) {
+	typ, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+	b, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	if int8(typ) != msgpackTimestamp {
+		return nil, fmt.Errorf("unsupported extension type %d", int8(typ))
+	}
+	switch len(b) {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(b)), 0).UTC(), nil
+	case 8:
+		u := binary.BigEndian.Uint64(b)
+		return time.Unix(int64(u&(1<<34-1)), int64(u>>34)).UTC(), nil
+	case 12:
+		nsec := binary.BigEndian.Uint32(b)
+		sec := int64(binary.BigEndian.Uint64(b[4:]))
+		return time.Unix(sec, int64(nsec)).UTC(), nil
+	default:
+		return nil, fmt.Errorf("invalid timestamp of %d bytes", len(b))
+	}
+}
@@ -0,0 +1,55 @@
+//go:build js && wasm
+
+package core
+
+import (
+	"errors"
+
+	"github.com/primate-run/go/codec"
+)
+
+// CBOR decodes a CBOR body holding a map into a Dict.
+func (body *Body) CBOR() (Dict, error) {
+	return DecodeCBOR[Dict](body)
+}
+
+// DecodeCBOR decodes a CBOR body into a T, naming struct fields by their
+// json tag.
+func DecodeCBOR[T any](body *Body) (T, error) {
+	var value T
+	if body.Kind() == KindNone {
+		return value, errors.New("no content-type declared; use route.With{ContentType: route.CBOR}")
+	}
+	if body.kind != KindCBOR {
+		return value, errors.New("expected cbor body; declare route.With{ContentType: route.CBOR}")
+	}
+	data, err := body.loadBlob()
+	if err != nil {
+		return value, err
+	}
+	err = codec.UnmarshalCBOR(data, &value)
+	return value, err
+}
+
+// MsgPack decodes a MessagePack body holding a map into a Dict.
+func (body *Body) MsgPack() (Dict, error) {
+	return DecodeMsgPack[Dict](body)
+}
+
+// DecodeMsgPack decodes a MessagePack body into a T, naming struct fields
+// by their json tag.
+func DecodeMsgPack[T any](body *Body) (T, error) {
+	var value T
+	if body.Kind() == KindNone {
+		return value, errors.New("no content-type declared; use route.With{ContentType: route.MsgPack}")
+	}
+	if body.kind != KindMsgPack {
+		return value, errors.New("expected msgpack body; declare route.With{ContentType: route.MsgPack}")
+	}
+	data, err := body.loadBlob()
+	if err != nil {
+		return value, err
+	}
+	err = codec.UnmarshalMsgPack(data, &value)
+	return value, err
+}
@@ -3,11 +3,21 @@
 package core
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"maps"
+	"mime"
+	"net/url"
+	"strings"
 	"sync"
 	"syscall/js"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/primate-run/go/pema"
 )
 
 type Kind int
@@ -19,28 +29,101 @@ const (
 	KindForm
 	KindMultipart
 	KindBlob
+	KindNDJSON
+	KindJSONSeq
+	KindXML
+	KindCBOR
+	KindMsgPack
 )
 
-func parseKind(s string) Kind {
-	switch s {
+// KindOf maps a Content-Type header value onto a body kind, ignoring
+// parameters such as charset.
+func KindOf(contentType string) Kind {
+	kind, _ := parseKind(contentType)
+	return kind
+}
+
+// parseKind maps a media type onto a kind, also returning its parameters.
+// Structured syntax suffixes count as their base type, so
+// application/problem+json is JSON and application/soap+xml is XML.
+func parseKind(s string) (Kind, map[string]string) {
+	mediaType, params, err := mime.ParseMediaType(s)
+	if err != nil {
+		return KindNone, nil
+	}
+	switch mediaType {
 	case "text/plain":
-		return KindText
+		return KindText, params
 	case "application/json":
-		return KindJSON
+		return KindJSON, params
 	case "application/x-www-form-urlencoded":
-		return KindForm
+		return KindForm, params
 	case "multipart/form-data":
-		return KindMultipart
+		return KindMultipart, params
 	case "application/octet-stream":
-		return KindBlob
+		return KindBlob, params
+	case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/x-jsonlines":
+		return KindNDJSON, params
+	case "application/json-seq":
+		return KindJSONSeq, params
+	case "application/xml", "text/xml":
+		return KindXML, params
+	case "application/cbor":
+		return KindCBOR, params
+	case "application/msgpack", "application/x-msgpack", "application/vnd.msgpack":
+		return KindMsgPack, params
+	}
+	switch {
+	case strings.HasSuffix(mediaType, "+json"):
+		return KindJSON, params
+	case strings.HasSuffix(mediaType, "+xml"):
+		return KindXML, params
+	case strings.HasSuffix(mediaType, "+cbor"):
+		return KindCBOR, params
+	case strings.HasPrefix(mediaType, "text/"):
+		return KindText, params
 	default:
-		return KindNone
+		return KindNone, params
 	}
 }
 
+// Limits bounds how much of a body is copied into Go memory, in bytes.
+// Zero or negative values mean no limit.
+type Limits struct {
+	Body int64
+	Part int64
+	File int64
+}
+
+// ErrTooLarge is matched by errors.Is for every limit violation.
+var ErrTooLarge = errors.New("request entity too large")
+
+type TooLargeError struct {
+	What  string
+	Limit int64
+	Size  int64
+}
+
+func (e *TooLargeError) Error() string {
+	return fmt.Sprintf("%s of %d bytes exceeds limit of %d bytes", e.What, e.Size, e.Limit)
+}
+
+func (e *TooLargeError) Is(target error) bool { return target == ErrTooLarge }
+
+func exceeds(limit, size int64) bool { return limit > 0 && size > limit }
+
 type Body struct {
-	jsObj js.Value
-	kind  Kind
+	jsObj    js.Value
+	kind     Kind
+	charset  string
+	boundary string
+	limits   Limits
+
+	// auto bodies are sniffed on first use, after any Content-Encoding
+	// is known
+	auto      bool
+	onceKind  sync.Once
+	encodings []string
 
 	onceText sync.Once
 	text     string
@@ -54,56 +137,105 @@ type Body struct {
 	formRaw  []byte
 	formErr  error
 
-	onceMultipart sync.Once
-	multipartRaw  []byte
-	multipartErr  error
+	onceMultipart  sync.Once
+	multipartRaw   []byte
+	multipartFiles []UploadFile
+	multipartErr   error
 
 	onceBlob sync.Once
 	blobData []byte
 	blobType string
 	blobErr  error
+
+	onceReader sync.Once
+	reader     io.ReadCloser
+
+	valid Dict
 }
 
-func NewBodyFromJS(v js.Value, contentType string) *Body {
-	return &Body{
-		jsObj: v,
-		kind:  parseKind(contentType),
+// NewBodyFromJS wraps a JS request body of the given content type. The
+// media range */* sniffs the kind from the body itself. Accessors fail
+// with ErrTooLarge rather than copy more than limits allow.
+func NewBodyFromJS(v js.Value, contentType string, limits ...Limits) *Body {
+	kind, params := parseKind(contentType)
+	body := &Body{
+		jsObj:    v,
+		kind:     kind,
+		charset:  strings.ToLower(params["charset"]),
+		boundary: params["boundary"],
 	}
+	if len(limits) > 0 {
+		body.limits = limits[0]
+	}
+	body.auto = strings.TrimSpace(contentType) == "*/*"
+	return body
 }
 
-func (body *Body) Kind() Kind { return body.kind }
+func (body *Body) Kind() Kind {
+	body.onceKind.Do(func() {
+		if body.auto {
+			body.kind = body.sniff()
+		}
+	})
+	return body.kind
+}
 
 func (body *Body) Text() (string, error) {
-	if body.kind == KindNone {
+	if body.Kind() == KindNone {
 		return "", errors.New("no content-type declared; use route.With{ContentType: route.Text}")
 	}
 	if body.kind != KindText {
 		return "", errors.New("expected text body; declare route.With{ContentType: route.Text}")
 	}
 	body.onceText.Do(func() {
-		body.text = body.jsObj.Call("textSync").String()
+		if isUTF8(body.charset) && !body.encoded() {
+			body.text, body.textErr = body.stringSync("textSync")
+			return
+		}
+		// the JS side decodes as UTF-8 and cannot decompress, so other
+		// charsets and compressed bodies decode here
+		data, err := body.loadBlob()
+		if err != nil {
+			body.textErr = err
+			return
+		}
+		body.text, body.textErr = decode(data, body.charset)
 	})
 	return body.text, body.textErr
 }
 
-func (body *Body) JSON() (Dict, error) {
-	if body.kind == KindNone {
+// JSON decodes the body into a Dict. Numbers decode as float64 unless
+// useNumber is set, in which case they are kept as json.Number so that
+// large integers survive intact.
+func (body *Body) JSON(useNumber ...bool) (Dict, error) {
+	if body.Kind() == KindNone {
 		return nil, errors.New("no content-type declared; use route.With{ContentType: route.JSON}")
 	}
 	if body.kind != KindJSON {
 		return nil, errors.New("expected json body; declare route.With{ContentType: route.JSON}")
 	}
 	body.onceJSON.Do(func() {
-		s := body.jsObj.Call("jsonSync").String()
-		body.jsonRaw = []byte(s)
+		if body.encoded() {
+			body.jsonRaw, body.jsonErr = body.loadBlob()
+			return
+		}
+		s, err := body.stringSync("jsonSync")
+		body.jsonRaw, body.jsonErr = []byte(s), err
 	})
 	if body.jsonErr != nil {
 		return nil, body.jsonErr
 	}
 
 	var data Dict
-	dec := json.NewDecoder(bytesReader(body.jsonRaw))
+	dec := json.NewDecoder(bytes.NewReader(body.jsonRaw))
+	if len(useNumber) > 0 && useNumber[0] {
+		dec.UseNumber()
+	}
 	if err := dec.Decode(&data); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field == "" {
+			return nil, fmt.Errorf("expected json object, got %s; read other values with Reader", typeErr.Value)
+		}
 		return nil, err
 	}
 
@@ -111,15 +243,19 @@ func (body *Body) JSON() (Dict, error) {
 }
 
 func (body *Body) Form() (Dict, error) {
-	if body.kind == KindNone {
+	if body.Kind() == KindNone {
 		return nil, errors.New("no content-type declared; use route.With{ContentType: route.Form}")
 	}
 	if body.kind != KindForm {
 		return nil, errors.New("expected form body; declare route.With{ContentType: route.Form}")
 	}
 	body.onceForm.Do(func() {
-		s := body.jsObj.Call("formSync").String()
-		body.formRaw = []byte(s)
+		if body.encoded() {
+			body.formRaw, body.formErr = body.decodeForm()
+			return
+		}
+		s, err := body.stringSync("formSync")
+		body.formRaw, body.formErr = []byte(s), err
 	})
 	if body.formErr != nil {
 		return nil, body.formErr
@@ -133,29 +269,54 @@ func (body *Body) Form() (Dict, error) {
 	return data, nil
 }
 
-type UploadFile struct {
-	Field string
-	Name  string
-	Type  string
-	Size  int64
-	Bytes []byte
-}
-
 type Multipart struct {
 	Form  Dict
 	Files []UploadFile
 }
 
+// Parse validates the form fields and uploaded files together. Files are
+// keyed by their form field, as a single UploadFile or, when a field
+// carries several, as []UploadFile.
+func (m Multipart) Parse(schema *pema.SchemaBuilder, coerce ...bool) (Dict, error) {
+	return schema.Parse(m.dict(), coerce...)
+}
+
+func (m Multipart) dict() Dict {
+	data := make(Dict, len(m.Form)+len(m.Files))
+	maps.Copy(data, m.Form)
+	byField := map[string][]UploadFile{}
+	for _, file := range m.Files {
+		byField[file.Field] = append(byField[file.Field], file)
+	}
+	for field, files := range byField {
+		if len(files) == 1 {
+			data[field] = files[0]
+		} else {
+			data[field] = files
+		}
+	}
+	return data
+}
+
 func (body *Body) Multipart() (Multipart, error) {
-	if body.kind == KindNone {
+	if body.Kind() == KindNone {
 		return Multipart{}, errors.New("no content-type declared; use route.With{ContentType: route.Multipart}")
 	}
 	if body.kind != KindMultipart {
 		return Multipart{}, errors.New("expected multipart body; declare route.With{ContentType: route.Multipart}")
 	}
 	body.onceMultipart.Do(func() {
-		s := body.jsObj.Call("formSync").String()
+		if body.encoded() {
+			body.multipartRaw, body.multipartFiles, body.multipartErr = body.decodeMultipart()
+			return
+		}
+		s, err := body.stringSync("formSync")
+		if err != nil {
+			body.multipartErr = err
+			return
+		}
 		body.multipartRaw = []byte(s)
+		body.multipartFiles, body.multipartErr = body.loadFiles(int64(len(s)))
 	})
 	if body.multipartErr != nil {
 		return Multipart{}, body.multipartErr
@@ -165,28 +326,50 @@ func (body *Body) Multipart() (Multipart, error) {
 	if err := json.Unmarshal(body.multipartRaw, &form); err != nil {
 		return Multipart{}, err
 	}
+	if body.limits.Part > 0 {
+		for field, value := range form {
+			if s, ok := value.(string); ok && exceeds(body.limits.Part, int64(len(s))) {
+				return Multipart{}, &TooLargeError{
+					What: fmt.Sprintf("field %q", field), Limit: body.limits.Part, Size: int64(len(s)),
+				}
+			}
+		}
+	}
+
+	return Multipart{Form: form, Files: body.multipartFiles}, nil
+}
 
+// loadFiles copies the uploaded files, checking each file's size and the
+// running total against the limits before copying it.
+func (body *Body) loadFiles(total int64) ([]UploadFile, error) {
 	arr := body.jsObj.Call("filesSync")
-	var files []UploadFile
-	if !arr.IsUndefined() && !arr.IsNull() {
-		n := arr.Length()
-		files = make([]UploadFile, 0, n)
-		for i := range n {
-			it := arr.Index(i)
-			field := it.Get("field").String()
-			name := it.Get("name").String()
-			typ := it.Get("type").String()
-			size := int64(it.Get("size").Int())
-			u8 := it.Get("bytes")
-			buf := make([]byte, u8.Get("length").Int())
-			js.CopyBytesToGo(buf, u8)
-			files = append(files, UploadFile{
-				Field: field, Name: name, Type: typ, Size: size, Bytes: buf,
-			})
+	if arr.IsUndefined() || arr.IsNull() {
+		return nil, nil
+	}
+	n := arr.Length()
+	files := make([]UploadFile, 0, n)
+	for i := range n {
+		it := arr.Index(i)
+		name := it.Get("name").String()
+		u8 := it.Get("bytes")
+		length := int64(u8.Get("length").Int())
+		if exceeds(body.limits.File, length) {
+			return nil, &TooLargeError{What: fmt.Sprintf("file %q", name), Limit: body.limits.File, Size: length}
+		}
+		if total += length; exceeds(body.limits.Body, total) {
+			return nil, &TooLargeError{What: "body", Limit: body.limits.Body, Size: total}
 		}
+		buf := make([]byte, length)
+		js.CopyBytesToGo(buf, u8)
+		files = append(files, UploadFile{
+			Field: it.Get("field").String(),
+			Name:  name,
+			Type:  it.Get("type").String(),
+			Size:  int64(it.Get("size").Int()),
+			Bytes: buf,
+		})
 	}
-
-	return Multipart{Form: form, Files: files}, nil
+	return files, nil
 }
 
 type Blob struct {
@@ -195,31 +378,142 @@ type Blob struct {
 }
 
 func (body *Body) Blob() (Blob, error) {
-	if body.kind == KindNone {
+	if body.Kind() == KindNone {
 		return Blob{}, errors.New("no content-type declared; use route.With{ContentType: route.Blob}")
 	}
 	if body.kind != KindBlob {
 		return Blob{}, errors.New("expected blob body; declare route.With{ContentType: route.Blob}")
 	}
+	body.loadBlob()
+	return Blob{Data: body.blobData, Type: body.blobType}, body.blobErr
+}
+
+func (body *Body) loadBlob() ([]byte, error) {
 	body.onceBlob.Do(func() {
+		if body.encoded() {
+			stream := body.Reader()
+			defer stream.Close()
+			body.blobData, body.blobErr = io.ReadAll(stream)
+			body.blobType = body.jsObj.Call("blobTypeSync").String()
+			return
+		}
 		u8 := body.jsObj.Call("blobSync")
-		n := u8.Get("length").Int()
+		n := int64(u8.Get("length").Int())
+		if exceeds(body.limits.Body, n) {
+			body.blobErr = &TooLargeError{What: "body", Limit: body.limits.Body, Size: n}
+			return
+		}
 		buf := make([]byte, n)
 		js.CopyBytesToGo(buf, u8)
 		body.blobData = buf
 		body.blobType = body.jsObj.Call("blobTypeSync").String()
 	})
-	return Blob{Data: body.blobData, Type: body.blobType}, body.blobErr
+	return body.blobData, body.blobErr
 }
 
-type byteReader []byte
+// stringSync calls a bridge method returning a string, refusing strings
+// over the body limit before copying them out of JS. A UTF-16 code unit
+// encodes to at least one UTF-8 byte, so the JS length is a lower bound.
+func (body *Body) stringSync(method string) (string, error) {
+	v := body.jsObj.Call(method)
+	if units := jsLength(v); exceeds(body.limits.Body, units) {
+		return "", &TooLargeError{What: "body", Limit: body.limits.Body, Size: units}
+	}
+	s := v.String()
+	if exceeds(body.limits.Body, int64(len(s))) {
+		return "", &TooLargeError{What: "body", Limit: body.limits.Body, Size: int64(len(s))}
+	}
+	return s, nil
+}
 
-func (r byteReader) Read(p []byte) (int, error) {
-	n := copy(p, r)
-	if n < len(r) {
-		return n, nil
+// jsLength is the length of a JS string in UTF-16 code units. syscall/js
+// reads no properties of primitives, so it goes through a String object,
+// which does not copy the string into Go.
+func jsLength(v js.Value) int64 {
+	if v.Type() != js.TypeString {
+		return 0
 	}
-	return n, io.EOF
+	return int64(js.Global().Get("String").New(v).Get("length").Int())
 }
 
-func bytesReader(b []byte) io.Reader { return byteReader(b) }
+// Validate parses the body according to its kind and validates it against
+// schema. Form and multipart values arrive as strings and are coerced. The
+// result is kept and returned by Valid.
+func (body *Body) Validate(schema *pema.SchemaBuilder) (Dict, error) {
+	data, err := body.Data()
+	if err != nil {
+		return nil, err
+	}
+	data, err = schema.Parse(data, body.kind == KindForm || body.kind == KindMultipart)
+	if err != nil {
+		return nil, err
+	}
+	body.valid = data
+	return data, nil
+}
+
+// Data returns a JSON, CBOR, MessagePack, form or multipart body as a
+// Dict, whichever the request was encoded as. Multipart files are keyed by field as in
+// Multipart.Parse.
+func (body *Body) Data() (Dict, error) {
+	switch body.Kind() {
+	case KindJSON:
+		return body.JSON()
+	case KindCBOR:
+		return body.CBOR()
+	case KindMsgPack:
+		return body.MsgPack()
+	case KindForm:
+		return body.Form()
+	case KindMultipart:
+		multipart, err := body.Multipart()
+		if err != nil {
+			return nil, err
+		}
+		return multipart.dict(), nil
+	case KindNone:
+		return nil, errors.New("no content-type declared; use route.With{ContentTypes: []route.ContentType{route.JSON, route.Form}}")
+	default:
+		return nil, errors.New("expected json, cbor, msgpack, form or multipart body; use Text or Blob for other bodies")
+	}
+}
+
+// Valid returns the body as validated by Validate, nil before.
+func (body *Body) Valid() Dict { return body.valid }
+
+// sniff guesses the kind of an undeclared body: JSON if it parses as an
+// object, which JSON and Data decode into, XML if it parses as such, a
+// form if it reads as a urlencoded query, text if it is printable UTF-8
+// and a blob otherwise. Other JSON values are thus read as text.
+func (body *Body) sniff() Kind {
+	data, err := body.loadBlob()
+	if err != nil {
+		// let Blob report the error
+		return KindBlob
+	}
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), " \t\r\n")
+	switch {
+	case len(trimmed) == 0:
+		return KindText
+	case trimmed[0] == '{' && json.Valid(trimmed):
+		return KindJSON
+	case trimmed[0] == '<' && isXML(trimmed):
+		return KindXML
+	case !utf8.Valid(data) || bytes.ContainsFunc(data, func(r rune) bool {
+		return unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t'
+	}):
+		return KindBlob
+	case isForm(data):
+		return KindForm
+	default:
+		return KindText
+	}
+}
+
+func isForm(data []byte) bool {
+	if !bytes.Contains(data, []byte("=")) || bytes.ContainsAny(data, " \t\r\n") {
+		return false
+	}
+	_, err := url.ParseQuery(string(data))
+	return err == nil
+}
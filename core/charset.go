@@ -0,0 +1,85 @@
+//go:build js && wasm
+
+package core
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// windows-1252 differs from ISO-8859-1 only in 0x80-0x9F
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+func isUTF8(charset string) bool {
+	switch charset {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		return true
+	default:
+		return false
+	}
+}
+
+// decode converts data in charset to a UTF-8 string.
+func decode(data []byte, charset string) (string, error) {
+	switch charset {
+	case "iso-8859-1", "latin1", "l1", "iso_8859-1", "iso8859-1":
+		var b strings.Builder
+		b.Grow(len(data))
+		for _, c := range data {
+			b.WriteRune(rune(c))
+		}
+		return b.String(), nil
+	case "windows-1252", "cp1252":
+		var b strings.Builder
+		b.Grow(len(data))
+		for _, c := range data {
+			if c >= 0x80 && c <= 0x9F {
+				b.WriteRune(windows1252[c-0x80])
+			} else {
+				b.WriteRune(rune(c))
+			}
+		}
+		return b.String(), nil
+	case "utf-16", "utf-16le", "utf-16be":
+		return decodeUTF16(data, charset)
+	default:
+		if isUTF8(charset) {
+			if !utf8.Valid(data) {
+				return "", fmt.Errorf("body is not valid %s", charset)
+			}
+			return string(data), nil
+		}
+		return "", fmt.Errorf("unsupported charset %q", charset)
+	}
+}
+
+// decodeUTF16 honours a byte order mark; without one, plain utf-16 is
+// read as big endian per RFC 2781.
+func decodeUTF16(data []byte, charset string) (string, error) {
+	var order binary.ByteOrder = binary.BigEndian
+	if charset == "utf-16le" {
+		order = binary.LittleEndian
+	}
+	if len(data) >= 2 {
+		switch {
+		case data[0] == 0xFE && data[1] == 0xFF:
+			order, data = binary.BigEndian, data[2:]
+		case data[0] == 0xFF && data[1] == 0xFE:
+			order, data = binary.LittleEndian, data[2:]
+		}
+	}
+	if len(data)%2 != 0 {
+		return "", fmt.Errorf("body is not valid %s: odd length", charset)
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	return string(utf16.Decode(units)), nil
+}
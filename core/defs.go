@@ -7,3 +7,4 @@ import "github.com/primate-run/go/types"
 type Object[T any] = types.Object[T]
 type Array[T any] = types.Array[T]
 type Dict = types.Dict
+type UploadFile = types.UploadFile
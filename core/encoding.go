@@ -0,0 +1,154 @@
+//go:build js && wasm
+
+package core
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// ErrUnsupportedEncoding is matched by errors.Is when a body's
+// Content-Encoding cannot be decoded.
+var ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+
+// SetContentEncoding declares the encodings applied to the body, as given
+// by the request's Content-Encoding header. gzip and deflate bodies are
+// then decompressed by every accessor, the decompressed size counting
+// against the body limit. It must be called before the body is read.
+func (body *Body) SetContentEncoding(header string) error {
+	var encodings []string
+	for encoding := range strings.SplitSeq(header, ",") {
+		switch encoding = strings.ToLower(strings.TrimSpace(encoding)); encoding {
+		case "", "identity":
+		case "gzip", "x-gzip", "deflate":
+			encodings = append(encodings, encoding)
+		default:
+			return fmt.Errorf("%w %q, expected gzip or deflate", ErrUnsupportedEncoding, encoding)
+		}
+	}
+	body.encodings = encodings
+	return nil
+}
+
+func (body *Body) encoded() bool { return len(body.encodings) > 0 }
+
+// decodingReader decompresses the raw body stream. The decompressors are
+// set up on first read, as they read headers from the stream.
+type decodingReader struct {
+	body *Body
+	raw  *bodyReader
+	r    io.Reader
+	err  error
+}
+
+func (d *decodingReader) Read(p []byte) (int, error) {
+	if d.r == nil && d.err == nil {
+		d.r, d.err = d.body.decompress(d.raw)
+	}
+	if d.err != nil {
+		return 0, d.err
+	}
+	return d.r.Read(p)
+}
+
+func (d *decodingReader) Close() error { return d.raw.Close() }
+
+// decompress undoes the encodings in reverse order of application. The
+// output is bounded by the body limit, so a small payload cannot expand
+// without bound.
+func (body *Body) decompress(r io.Reader) (io.Reader, error) {
+	for i := len(body.encodings) - 1; i >= 0; i-- {
+		var err error
+		switch body.encodings[i] {
+		case "gzip", "x-gzip":
+			r, err = gzip.NewReader(r)
+		case "deflate":
+			r, err = inflate(r)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s body: %w", body.encodings[i], err)
+		}
+	}
+	return &limitedReader{r: r, what: "decompressed body", limit: body.limits.Body}, nil
+}
+
+// inflate reads deflate as specified, zlib-wrapped, but also accepts the
+// raw deflate streams some clients send instead.
+func inflate(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err != nil && len(header) < 2 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// decodeForm parses a decompressed urlencoded body into the JSON the
+// formSync bridge method would return. Repeated keys become arrays.
+func (body *Body) decodeForm() ([]byte, error) {
+	data, err := body.loadBlob()
+	if err != nil {
+		return nil, err
+	}
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(flatten(values))
+}
+
+// decodeMultipart reads a decompressed multipart body part by part, the
+// part and file limits applying as in MultipartParts.
+func (body *Body) decodeMultipart() ([]byte, []UploadFile, error) {
+	values := url.Values{}
+	var files []UploadFile
+	for part, err := range body.MultipartParts() {
+		if err != nil {
+			return nil, nil, err
+		}
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !part.IsFile() {
+			values.Add(part.FieldName, string(data))
+			continue
+		}
+		files = append(files, UploadFile{
+			Field: part.FieldName,
+			Name:  part.FileName,
+			Type:  part.Type,
+			Size:  int64(len(data)),
+			Bytes: data,
+		})
+	}
+	form, err := json.Marshal(flatten(values))
+	return form, files, err
+}
+
+func flatten(values url.Values) Dict {
+	data := make(Dict, len(values))
+	for key, all := range values {
+		if len(all) == 1 {
+			data[key] = all[0]
+			continue
+		}
+		items := make([]any, len(all))
+		for i, value := range all {
+			items[i] = value
+		}
+		data[key] = items
+	}
+	return data
+}
@@ -0,0 +1,68 @@
+//go:build js && wasm
+
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// record separator of RFC 7464 JSON text sequences
+const recordSeparator = 0x1E
+
+// NDJSON decodes a newline-delimited JSON body (or a JSON text sequence)
+// record by record as it streams in. A malformed record yields an error
+// and iteration continues with the next one; read errors end it.
+func (body *Body) NDJSON() iter.Seq2[Dict, error] {
+	return DecodeNDJSON[Dict](body)
+}
+
+// DecodeNDJSON is NDJSON decoding each record into a T.
+func DecodeNDJSON[T any](body *Body) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		if body.Kind() == KindNone {
+			yield(zero, errors.New("no content-type declared; use route.With{ContentType: route.NDJSON}"))
+			return
+		}
+		if body.kind != KindNDJSON && body.kind != KindJSONSeq {
+			yield(zero, errors.New("expected ndjson body; declare route.With{ContentType: route.NDJSON}"))
+			return
+		}
+
+		delimiter := byte('\n')
+		if body.kind == KindJSONSeq {
+			delimiter = recordSeparator
+		}
+		stream := body.Reader()
+		defer stream.Close()
+		r := bufio.NewReader(stream)
+		n := 0
+		for {
+			line, err := r.ReadBytes(delimiter)
+			if err != nil && err != io.EOF {
+				yield(zero, err)
+				return
+			}
+			if record := bytes.TrimSpace(bytes.TrimSuffix(line, []byte{delimiter})); len(record) > 0 {
+				n++
+				var value T
+				if uerr := json.Unmarshal(record, &value); uerr != nil {
+					if !yield(zero, fmt.Errorf("record %d: %w", n, uerr)) {
+						return
+					}
+				} else if !yield(value, nil) {
+					return
+				}
+			}
+			if err == io.EOF {
+				return
+			}
+		}
+	}
+}
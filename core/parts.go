@@ -0,0 +1,92 @@
+//go:build js && wasm
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"mime/multipart"
+	"net/textproto"
+)
+
+// Part is one part of a multipart body, read straight from the request
+// stream. It is only valid until the iteration moves on.
+type Part struct {
+	Header    textproto.MIMEHeader
+	FieldName string
+	FileName  string
+	Type      string
+	io.Reader
+}
+
+// IsFile reports whether the part is an uploaded file rather than a form
+// field.
+func (part *Part) IsFile() bool { return part.FileName != "" }
+
+// MultipartParts iterates over the parts of a multipart body as they
+// arrive, so files can be processed without holding them in memory. The
+// per-part and per-file limits apply to each part's reader.
+func (body *Body) MultipartParts() iter.Seq2[*Part, error] {
+	return func(yield func(*Part, error) bool) {
+		if body.Kind() == KindNone {
+			yield(nil, errors.New("no content-type declared; use route.With{ContentType: route.Multipart}"))
+			return
+		}
+		if body.kind != KindMultipart {
+			yield(nil, errors.New("expected multipart body; declare route.With{ContentType: route.Multipart}"))
+			return
+		}
+		if body.boundary == "" {
+			yield(nil, errors.New("multipart body has no boundary in its Content-Type"))
+			return
+		}
+
+		stream := body.Reader()
+		defer stream.Close()
+		mr := multipart.NewReader(stream, body.boundary)
+		for {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			part := &Part{
+				Header:    p.Header,
+				FieldName: p.FormName(),
+				FileName:  p.FileName(),
+				Type:      p.Header.Get("Content-Type"),
+			}
+			what, limit := fmt.Sprintf("field %q", part.FieldName), body.limits.Part
+			if part.IsFile() {
+				what, limit = fmt.Sprintf("file %q", part.FileName), body.limits.File
+			}
+			part.Reader = &limitedReader{r: p, what: what, limit: limit}
+			if !yield(part, nil) {
+				return
+			}
+		}
+	}
+}
+
+// limitedReader fails with a TooLargeError once more than limit bytes
+// have been read, unlike io.LimitReader which stops silently.
+type limitedReader struct {
+	r     io.Reader
+	what  string
+	limit int64
+	read  int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if exceeds(l.limit, l.read) {
+		return n, &TooLargeError{What: l.what, Limit: l.limit, Size: l.read}
+	}
+	return n, err
+}
@@ -0,0 +1,101 @@
+//go:build js && wasm
+
+package core
+
+import (
+	"errors"
+	"io"
+	"syscall/js"
+)
+
+var (
+	errReaderClosed = errors.New("body reader closed")
+	errNoReadSync   = errors.New("body cannot be streamed: the bridge has no readSync")
+)
+
+// bodyReader pulls the body from the JS ReadableStream one chunk at a time
+// through the readSync bridge method, which returns the next Uint8Array or
+// null once the stream is exhausted. Chunks stay in JS until read, so
+// memory use is bounded by the caller's buffer.
+type bodyReader struct {
+	body   *Body
+	chunk  js.Value
+	left   int
+	total  int64
+	done   bool
+	closed bool
+}
+
+// Reader streams the body, whatever its kind, decompressed according to
+// its Content-Encoding. Reading it consumes the stream, so it cannot be
+// combined with the other accessors; repeated calls return the same
+// reader.
+func (body *Body) Reader() io.ReadCloser {
+	body.onceReader.Do(func() {
+		raw := &bodyReader{body: body}
+		if body.encoded() {
+			body.reader = &decodingReader{body: body, raw: raw}
+		} else {
+			body.reader = raw
+		}
+	})
+	return body.reader
+}
+
+func (r *bodyReader) Read(p []byte) (int, error) {
+	if r.closed {
+		return 0, errReaderClosed
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	for r.left == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if !hasMethod(r.body.jsObj, "readSync") {
+			return 0, errNoReadSync
+		}
+		chunk := r.body.jsObj.Call("readSync")
+		if chunk.IsNull() || chunk.IsUndefined() {
+			r.done = true
+			return 0, io.EOF
+		}
+		r.chunk, r.left = chunk, chunk.Get("length").Int()
+		r.total += int64(r.left)
+		if limit := r.body.limits.Body; exceeds(limit, r.total) {
+			r.Close()
+			return 0, &TooLargeError{What: "body", Limit: limit, Size: r.total}
+		}
+	}
+	n := js.CopyBytesToGo(p, r.chunk)
+	r.left -= n
+	if r.left > 0 {
+		r.chunk = r.chunk.Call("subarray", n)
+	} else {
+		r.chunk = js.Undefined()
+	}
+	return n, nil
+}
+
+// Close cancels the rest of the stream.
+func (r *bodyReader) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	r.chunk, r.left = js.Undefined(), 0
+	if !r.done {
+		r.done = true
+		if hasMethod(r.body.jsObj, "cancelSync") {
+			r.body.jsObj.Call("cancelSync")
+		}
+	}
+	return nil
+}
+
+// hasMethod reports whether v is an object with a method name; bridges
+// and bodies of older hosts lack some.
+func hasMethod(v js.Value, name string) bool {
+	return v.Type() == js.TypeObject && v.Get(name).Type() == js.TypeFunction
+}
@@ -2,6 +2,8 @@
 
 package core
 
+import "strings"
+
 type URL struct {
 	Href         string
 	Origin       string
@@ -25,3 +27,26 @@ type Request struct {
 	Headers *RequestBag
 	Cookies *RequestBag
 }
+
+// Valid returns the body validated against the route's schema, see
+// route.With.
+func (request Request) Valid() Dict {
+	return request.Body.Valid()
+}
+
+// Header returns the named request header, matching the name
+// case-insensitively, or "" if it is absent.
+func (request Request) Header(name string) string {
+	if request.Headers == nil {
+		return ""
+	}
+	if value, exists := request.Headers.contents[name]; exists {
+		return value
+	}
+	for key, value := range request.Headers.contents {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
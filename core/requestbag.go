@@ -12,6 +12,7 @@ import (
 type RequestBag struct {
 	contents map[string]string
 	name     string
+	valid    Dict
 }
 
 func NewRequestBag(data Dict, name string) *RequestBag {
@@ -59,6 +60,22 @@ func (rb *RequestBag) Parse(schema *pema.SchemaBuilder, coerce ...bool) (Dict, e
 	return schema.Parse(data, coerce...)
 }
 
+// Validate parses the bag against schema with coercion on, as all values
+// arrive as strings. The result is kept and returned by Valid.
+func (rb *RequestBag) Validate(schema *pema.SchemaBuilder) (Dict, error) {
+	data, err := rb.Parse(schema, true)
+	if err != nil {
+		return nil, err
+	}
+	rb.valid = data
+	return data, nil
+}
+
+// Valid returns the bag as validated by Validate, nil before.
+func (rb *RequestBag) Valid() Dict {
+	return rb.valid
+}
+
 func (rb *RequestBag) ToJSON() map[string]string {
 	return maps.Clone(rb.contents)
 }
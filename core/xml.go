@@ -0,0 +1,74 @@
+//go:build js && wasm
+
+package core
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// XML decodes the body into v using encoding/xml. A charset given in the
+// Content-Type header takes precedence over the XML declaration's
+// encoding, as RFC 7303 requires.
+func (body *Body) XML(v any) error {
+	if body.Kind() == KindNone {
+		return errors.New("no content-type declared; use route.With{ContentType: route.XML}")
+	}
+	if body.kind != KindXML {
+		return errors.New("expected xml body; declare route.With{ContentType: route.XML}")
+	}
+	data, err := body.loadBlob()
+	if err != nil {
+		return err
+	}
+	var dec *xml.Decoder
+	if body.charset != "" && !isUTF8(body.charset) {
+		s, err := decode(data, body.charset)
+		if err != nil {
+			return err
+		}
+		dec = xml.NewDecoder(bytes.NewReader([]byte(s)))
+		// already UTF-8, whatever the declaration says
+		dec.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+			return input, nil
+		}
+	} else {
+		dec = xml.NewDecoder(bytes.NewReader(data))
+		dec.CharsetReader = charsetReader
+	}
+	return dec.Decode(v)
+}
+
+// charsetReader decodes documents declaring a non UTF-8 encoding.
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	s, err := decode(data, strings.ToLower(label))
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader([]byte(s)), nil
+}
+
+func isXML(data []byte) bool {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.CharsetReader = charsetReader
+	elements := 0
+	for {
+		token, err := dec.Token()
+		if err == io.EOF {
+			return elements > 0
+		}
+		if err != nil {
+			return false
+		}
+		if _, ok := token.(xml.StartElement); ok {
+			elements++
+		}
+	}
+}
@@ -0,0 +1,32 @@
+//go:build js && wasm
+
+package i18n
+
+import "github.com/primate-run/go/pema"
+
+// Issue renders a pema issue through T in the current locale, using the
+// issue's key (pema.too_small, pema.invalid_type, ...) and its params as
+// vars. Untranslated keys fall back to the default message.
+func Issue(issue pema.Issue) string {
+	key := issue.Key()
+	if message := T(key, issue.Vars()); message != "" && message != key {
+		return message
+	}
+	return issue.Message
+}
+
+// Issues returns the issues of a pema parse error with their messages
+// rendered through T in the current locale.
+func Issues(err error) []pema.Issue {
+	issues := pema.Issues(err)
+	for i := range issues {
+		issues[i].Message = Issue(issues[i])
+	}
+	return issues
+}
+
+// UsePema makes pema render validation messages through T, so that every
+// SchemaBuilder.Parse error is already localized.
+func UsePema() {
+	pema.SetResolver(Issue)
+}
@@ -0,0 +1,120 @@
+//go:build js && wasm
+
+package pema
+
+import (
+	"maps"
+	"slices"
+)
+
+type unknownKeys int
+
+const (
+	unknownStrip unknownKeys = iota
+	unknownStrict
+	unknownPassthrough
+)
+
+// Extend returns a copy of the schema with fields added, replacing any
+// field of the same name.
+func (s *SchemaBuilder) Extend(fields map[string]any) *SchemaBuilder {
+	clone := s.clone()
+	for name, field := range fields {
+		clone.fields[name] = wrap(field)
+	}
+	return clone
+}
+
+// Pick returns a copy of the schema with only the given fields. Schema
+// level refinements are dropped, as they may refer to removed fields.
+func (s *SchemaBuilder) Pick(keys ...string) *SchemaBuilder {
+	clone := s.clone()
+	clone.checks = nil
+	maps.DeleteFunc(clone.fields, func(name string, _ AnyField) bool {
+		return !slices.Contains(keys, name)
+	})
+	return clone
+}
+
+// Omit returns a copy of the schema without the given fields. Schema
+// level refinements are dropped, as they may refer to removed fields.
+func (s *SchemaBuilder) Omit(keys ...string) *SchemaBuilder {
+	clone := s.clone()
+	clone.checks = nil
+	for _, key := range keys {
+		delete(clone.fields, key)
+	}
+	return clone
+}
+
+// Partial returns a copy of the schema in which the given fields, or all
+// fields if none are given, are optional.
+func (s *SchemaBuilder) Partial(keys ...string) *SchemaBuilder {
+	clone := s.clone()
+	for name, field := range clone.fields {
+		if len(keys) > 0 && !slices.Contains(keys, name) {
+			continue
+		}
+		switch f := field.(type) {
+		case OptionalType:
+		case requiredType:
+			clone.fields[name] = OptionalType{field: f.field}
+		default:
+			clone.fields[name] = OptionalType{field: f}
+		}
+	}
+	return clone
+}
+
+// Required returns a copy of the schema in which the given fields, or all
+// fields if none are given, must be present.
+func (s *SchemaBuilder) Required(keys ...string) *SchemaBuilder {
+	clone := s.clone()
+	for name, field := range clone.fields {
+		if len(keys) > 0 && !slices.Contains(keys, name) {
+			continue
+		}
+		switch f := field.(type) {
+		case requiredType:
+		case OptionalType:
+			clone.fields[name] = requiredType{field: f.field}
+		default:
+			clone.fields[name] = requiredType{field: f}
+		}
+	}
+	return clone
+}
+
+// Merge returns a schema with the fields and refinements of both schemas.
+// Fields of other win on conflict, as does its unknown key policy.
+func (s *SchemaBuilder) Merge(other *SchemaBuilder) *SchemaBuilder {
+	clone := s.clone()
+	maps.Copy(clone.fields, other.fields)
+	clone.checks = append(clone.checks, other.checks...)
+	clone.unknown = other.unknown
+	return clone
+}
+
+// Strict returns a copy of the schema that rejects unknown keys.
+func (s *SchemaBuilder) Strict() *SchemaBuilder { return s.withUnknown(unknownStrict) }
+
+// Passthrough returns a copy of the schema that keeps unknown keys as is.
+func (s *SchemaBuilder) Passthrough() *SchemaBuilder { return s.withUnknown(unknownPassthrough) }
+
+// Strip returns a copy of the schema that drops unknown keys, the default.
+func (s *SchemaBuilder) Strip() *SchemaBuilder { return s.withUnknown(unknownStrip) }
+
+func (s *SchemaBuilder) withUnknown(policy unknownKeys) *SchemaBuilder {
+	clone := s.clone()
+	clone.unknown = policy
+	return clone
+}
+
+func (s *SchemaBuilder) clone() *SchemaBuilder {
+	return &SchemaBuilder{
+		fields:      maps.Clone(s.fields),
+		checks:      slices.Clone(s.checks),
+		description: s.description,
+		unknown:     s.unknown,
+	}
+}
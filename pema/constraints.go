@@ -0,0 +1,121 @@
+//go:build js && wasm
+
+package pema
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+var emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+func (t StringType) Parse(value any, coerce bool) (string, error) {
+	s, err := t.scalar(value, coerce)
+	if err != nil {
+		return "", err
+	}
+	length := utf8.RuneCountInString(s)
+	if t.min != nil && length < *t.min {
+		return "", issue(CodeTooSmall, Dict{"min": *t.min, "type": "string"},
+			"expected at least %d characters, got %d", *t.min, length)
+	}
+	if t.max != nil && length > *t.max {
+		return "", issue(CodeTooBig, Dict{"max": *t.max, "type": "string"},
+			"expected at most %d characters, got %d", *t.max, length)
+	}
+	if t.email && !emailPattern.MatchString(s) {
+		return "", issue(CodeInvalidString, Dict{"validation": "email"},
+			"expected email address, got %q", s)
+	}
+	if t.pattern != nil && !t.pattern.MatchString(s) {
+		return "", issue(CodeInvalidString, Dict{"validation": "pattern", "pattern": t.pattern.String()},
+			"expected string matching %s, got %q", t.pattern, s)
+	}
+	return s, nil
+}
+
+func (t StringType) Min(n int) StringType {
+	t.min = &n
+	return t
+}
+
+func (t StringType) Max(n int) StringType {
+	t.max = &n
+	return t
+}
+
+func (t StringType) Email() StringType {
+	t.email = true
+	return t
+}
+
+func (t StringType) Pattern(pattern string) StringType {
+	t.pattern = regexp.MustCompile(pattern)
+	return t
+}
+
+func (t IntType) Parse(value any, coerce bool) (int, error) {
+	i, err := t.scalar(value, coerce)
+	if err != nil {
+		return 0, err
+	}
+	return i, bounds(i, t.min, t.max)
+}
+
+func (t IntType) Min(n int) IntType {
+	t.min = &n
+	return t
+}
+
+func (t IntType) Max(n int) IntType {
+	t.max = &n
+	return t
+}
+
+func (t Int64Type) Parse(value any, coerce bool) (int64, error) {
+	i, err := t.scalar(value, coerce)
+	if err != nil {
+		return 0, err
+	}
+	return i, bounds(i, t.min, t.max)
+}
+
+func (t Int64Type) Min(n int64) Int64Type {
+	t.min = &n
+	return t
+}
+
+func (t Int64Type) Max(n int64) Int64Type {
+	t.max = &n
+	return t
+}
+
+func (t FloatType) Parse(value any, coerce bool) (float64, error) {
+	f, err := t.scalar(value, coerce)
+	if err != nil {
+		return 0, err
+	}
+	return f, bounds(f, t.min, t.max)
+}
+
+func (t FloatType) Min(n float64) FloatType {
+	t.min = &n
+	return t
+}
+
+func (t FloatType) Max(n float64) FloatType {
+	t.max = &n
+	return t
+}
+
+func bounds[N int | int64 | float64](n N, min, max *N) error {
+	if min != nil && n < *min {
+		return issue(CodeTooSmall, Dict{"min": *min, "type": "number"},
+			"expected at least %v, got %v", *min, n)
+	}
+	if max != nil && n > *max {
+		return issue(CodeTooBig, Dict{"max": *max, "type": "number"},
+			"expected at most %v, got %v", *max, n)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+//go:build js && wasm
+
+package pema
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+type EnumType struct {
+	values []string
+}
+
+type LiteralType[T comparable] struct {
+	value T
+}
+
+func (e EnumType) Parse(value any, coerce bool) (string, error) {
+	s, ok := value.(string)
+	if !ok {
+		if !coerce {
+			return "", issue(CodeInvalidEnum, Dict{"options": e.values, "received": typeName(value)},
+				"expected one of %s, got %s", quoteAll(e.values), typeName(value))
+		}
+		s = fmt.Sprintf("%v", value)
+	}
+	if !slices.Contains(e.values, s) {
+		return "", issue(CodeInvalidEnum, Dict{"options": e.values, "received": s},
+			"expected one of %s, got %q", quoteAll(e.values), s)
+	}
+	return s, nil
+}
+
+func (l LiteralType[T]) Parse(value any, coerce bool) (T, error) {
+	if v, ok := value.(T); ok && reflect.ValueOf(v).Comparable() && v == l.value {
+		return l.value, nil
+	}
+	// JSON numbers arrive as float64 or json.Number whatever the literal's type
+	if a, ok := number(value); ok {
+		if b, ok := number(l.value); ok && a == b {
+			return l.value, nil
+		}
+	}
+	// objects and arrays, as in JSON Schema's const and enum, compare by
+	// their contents
+	if !reflect.ValueOf(l.value).Comparable() && reflect.DeepEqual(normalize(value), normalize(l.value)) {
+		return l.value, nil
+	}
+	if coerce && fmt.Sprintf("%v", value) == fmt.Sprintf("%v", l.value) {
+		return l.value, nil
+	}
+	return l.value, issue(CodeInvalidLiteral, Dict{"expected": l.value, "received": value},
+		"expected %s, got %s", quote(l.value), quote(value))
+}
+
+func Enum(values ...string) EnumType {
+	return EnumType{values: slices.Clone(values)}
+}
+
+func Literal[T comparable](value T) LiteralType[T] {
+	return LiteralType[T]{value: value}
+}
+
+// normalize turns the numbers in value into float64 and its objects into
+// Dict, so that decoded JSON compares equal to the same data built in Go.
+func normalize(value any) any {
+	switch v := value.(type) {
+	case Dict:
+		out := make(Dict, len(v))
+		for key, item := range v {
+			out[key] = normalize(item)
+		}
+		return out
+	case map[string]any:
+		return normalize(Dict(v))
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = normalize(item)
+		}
+		return out
+	}
+	if f, ok := number(value); ok {
+		return f
+	}
+	return value
+}
+
+func quote(value any) string {
+	if s, ok := value.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func quoteAll[T any](values []T) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = quote(v)
+	}
+	return strings.Join(quoted, ", ")
+}
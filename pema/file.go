@@ -0,0 +1,159 @@
+//go:build js && wasm
+
+package pema
+
+import (
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/primate-run/go/types"
+)
+
+type UploadFile = types.UploadFile
+
+type FileType struct {
+	maxSize    *int64
+	mimeTypes  []string
+	extensions []string
+}
+
+type FilesType struct {
+	file FileType
+	min  int
+	max  int
+}
+
+func (f FileType) Parse(value any, coerce bool) (UploadFile, error) {
+	var file UploadFile
+	switch v := value.(type) {
+	case UploadFile:
+		file = v
+	case *UploadFile:
+		if v == nil {
+			return UploadFile{}, invalidType("file", nil)
+		}
+		file = *v
+	case []UploadFile:
+		if len(v) != 1 {
+			return UploadFile{}, issue(CodeTooBig, Dict{"max": 1, "type": "files"},
+				"expected a single file, got %d", len(v))
+		}
+		file = v[0]
+	default:
+		return UploadFile{}, invalidType("file", value)
+	}
+	return file, f.check(file)
+}
+
+func (f FileType) check(file UploadFile) error {
+	if f.maxSize != nil && file.Size > *f.maxSize {
+		return issue(CodeTooBig, Dict{"max": *f.maxSize, "type": "file"},
+			"expected file of at most %d bytes, got %d", *f.maxSize, file.Size)
+	}
+	if f.mimeTypes != nil && !slices.ContainsFunc(f.mimeTypes, func(pattern string) bool {
+		return matchMime(pattern, file.Type)
+	}) {
+		return issue(CodeInvalidFileType, Dict{"options": f.mimeTypes, "received": file.Type},
+			"expected file of type %s, got %q", quoteAll(f.mimeTypes), file.Type)
+	}
+	if f.extensions != nil {
+		ext := strings.ToLower(path.Ext(file.Name))
+		if !slices.Contains(f.extensions, ext) {
+			return issue(CodeInvalidFileType, Dict{"options": f.extensions, "received": ext},
+				"expected file with extension %s, got %q", quoteAll(f.extensions), file.Name)
+		}
+	}
+	return nil
+}
+
+// MaxSize limits the file to size bytes.
+func (f FileType) MaxSize(size int64) FileType {
+	f.maxSize = &size
+	return f
+}
+
+// MimeTypes restricts the file's media type; patterns like image/* match
+// a whole top-level type.
+func (f FileType) MimeTypes(mimeTypes ...string) FileType {
+	f.mimeTypes = slices.Clone(mimeTypes)
+	return f
+}
+
+// Extensions restricts the file name's extension, compared case
+// insensitively and with or without the leading dot.
+func (f FileType) Extensions(extensions ...string) FileType {
+	f.extensions = make([]string, len(extensions))
+	for i, ext := range extensions {
+		f.extensions[i] = "." + strings.TrimPrefix(strings.ToLower(ext), ".")
+	}
+	return f
+}
+
+// Multiple accepts between min and max files for the field; max <= 0
+// means no upper bound.
+func (f FileType) Multiple(min, max int) FilesType {
+	return FilesType{file: f, min: min, max: max}
+}
+
+func (f FilesType) Parse(value any, coerce bool) ([]UploadFile, error) {
+	var files []UploadFile
+	switch v := value.(type) {
+	case []UploadFile:
+		files = v
+	case UploadFile:
+		files = []UploadFile{v}
+	case nil:
+	default:
+		return nil, invalidType("files", value)
+	}
+	if len(files) < f.min {
+		return nil, issue(CodeTooSmall, Dict{"min": f.min, "type": "files"},
+			"expected at least %d files, got %d", f.min, len(files))
+	}
+	if f.max > 0 && len(files) > f.max {
+		return nil, issue(CodeTooBig, Dict{"max": f.max, "type": "files"},
+			"expected at most %d files, got %d", f.max, len(files))
+	}
+	var failed []Issue
+	for i, file := range files {
+		if err := f.file.check(file); err != nil {
+			failed = append(failed, issues(strconv.Itoa(i), err)...)
+		}
+	}
+	if failed != nil {
+		return nil, &ParseError{Issues: failed}
+	}
+	return files, nil
+}
+
+func File() FileType { return FileType{} }
+
+func matchMime(pattern, mime string) bool {
+	mime = strings.ToLower(strings.TrimSpace(strings.SplitN(mime, ";", 2)[0]))
+	pattern = strings.ToLower(pattern)
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.HasPrefix(mime, prefix+"/")
+	}
+	return pattern == "*" || pattern == "*/*" || pattern == mime
+}
+
+func (f FileType) jsonSchema() Dict {
+	schema := Dict{"type": "string", "format": "binary"}
+	if len(f.mimeTypes) == 1 {
+		schema["contentMediaType"] = f.mimeTypes[0]
+	}
+	return schema
+}
+
+func (f FilesType) jsonSchema() Dict {
+	schema := Dict{"type": "array", "items": f.file.jsonSchema()}
+	if f.min > 0 {
+		schema["minItems"] = f.min
+	}
+	if f.max > 0 {
+		schema["maxItems"] = f.max
+	}
+	return schema
+}
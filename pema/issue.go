@@ -0,0 +1,120 @@
+//go:build js && wasm
+
+package pema
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	CodeRequired             = "required"
+	CodeInvalidType          = "invalid_type"
+	CodeInvalidString        = "invalid_string"
+	CodeInvalidEnum          = "invalid_enum"
+	CodeInvalidLiteral       = "invalid_literal"
+	CodeInvalidUnion         = "invalid_union"
+	CodeInvalidDiscriminator = "invalid_discriminator"
+	CodeInvalidDate          = "invalid_date"
+	CodeInvalidDuration      = "invalid_duration"
+	CodeInvalidFileType      = "invalid_file_type"
+	CodeNotInteger           = "not_integer"
+	CodeTooSmall             = "too_small"
+	CodeTooBig               = "too_big"
+	CodeUnrecognizedKeys     = "unrecognized_keys"
+	CodeCustom               = "custom"
+)
+
+// Issue is a single validation failure. Path is dot-separated from the
+// schema root ("address.city", "items.0"), Code is one of the Code*
+// constants and Params carries the values referenced by Message.
+type Issue struct {
+	Path    string `json:"path"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Params  Dict   `json:"params,omitempty"`
+}
+
+func (i *Issue) Error() string {
+	if i.Path == "" {
+		return i.Message
+	}
+	return fmt.Sprintf("parsing failed for field '%s': %s", i.Path, i.Message)
+}
+
+// ParseError collects every issue found while parsing a value.
+type ParseError struct {
+	Issues []Issue `json:"issues"`
+}
+
+func (e *ParseError) Error() string {
+	messages := make([]string, len(e.Issues))
+	for i := range e.Issues {
+		messages[i] = e.Issues[i].Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func issue(code string, params Dict, format string, args ...any) *Issue {
+	return &Issue{Code: code, Message: fmt.Sprintf(format, args...), Params: params}
+}
+
+func invalidType(expected string, value any) *Issue {
+	received := typeName(value)
+	return issue(CodeInvalidType, Dict{"expected": expected, "received": received},
+		"expected %s, got %s", expected, received)
+}
+
+// issues flattens err into issues rooted at path.
+func issues(path string, err error) []Issue {
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		out := make([]Issue, len(parseErr.Issues))
+		for i, it := range parseErr.Issues {
+			it.Path = join(path, it.Path)
+			out[i] = it
+		}
+		return out
+	}
+	var single *Issue
+	if errors.As(err, &single) {
+		it := *single
+		it.Path = join(path, it.Path)
+		return []Issue{it}
+	}
+	return []Issue{{Path: path, Code: CodeCustom, Message: err.Error()}}
+}
+
+func join(prefix, path string) string {
+	switch {
+	case prefix == "":
+		return path
+	case path == "":
+		return prefix
+	default:
+		return prefix + "." + path
+	}
+}
+
+func typeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int32, int64, uint64, float64:
+		return "number"
+	case []any:
+		return "array"
+	case Dict, map[string]any:
+		return "object"
+	default:
+		if _, ok := number(value); ok {
+			return "number"
+		}
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,647 @@
+//go:build js && wasm
+
+package pema
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type importer struct {
+	root Dict
+	refs map[string]*RefType
+}
+
+// allType accepts a value matching all of its fields, for keywords such
+// as $ref and oneOf that sit beside others in a schema.
+type allType struct {
+	fields []AnyField
+}
+
+// untypedType applies the keywords of a schema without a type to the
+// instances they constrain and lets others through.
+type untypedType struct {
+	byType map[string]AnyField
+}
+
+// oneOfType accepts a value matching exactly one of its members.
+type oneOfType struct {
+	fields []AnyField
+}
+
+type NullType struct{}
+
+type requiredType struct {
+	field AnyField
+}
+
+// parse returns the value as parsed by the first field, with the keys
+// that the others parse added for objects.
+func (a allType) parse(value any, coerce bool) (any, error) {
+	var result any
+	var failed []Issue
+	for i, field := range a.fields {
+		parsed, err := field.parse(value, coerce)
+		if err != nil {
+			failed = append(failed, issues("", err)...)
+			continue
+		}
+		if i == 0 {
+			result = parsed
+			continue
+		}
+		base, ok := result.(Dict)
+		more, isDict := parsed.(Dict)
+		if !ok || !isDict {
+			continue
+		}
+		merged := maps.Clone(base)
+		for key, value := range more {
+			if _, ok := merged[key]; !ok {
+				merged[key] = value
+			}
+		}
+		result = merged
+	}
+	if failed != nil {
+		return nil, &ParseError{Issues: failed}
+	}
+	return result, nil
+}
+
+func (a allType) jsonSchema() Dict {
+	members := make([]Dict, len(a.fields))
+	for i, field := range a.fields {
+		members[i] = jsonSchemaOf(field)
+	}
+	return Dict{"allOf": members}
+}
+
+func (u untypedType) parse(value any, coerce bool) (any, error) {
+	if field, ok := u.byType[typeName(value)]; ok {
+		return field.parse(value, coerce)
+	}
+	return value, nil
+}
+
+func (u untypedType) jsonSchema() Dict {
+	schema := Dict{}
+	for _, field := range u.byType {
+		maps.Copy(schema, jsonSchemaOf(field))
+	}
+	delete(schema, "type")
+	return schema
+}
+
+func (o oneOfType) parse(value any, coerce bool) (any, error) {
+	var result any
+	var matched []int
+	var errs []string
+	for i, field := range o.fields {
+		parsed, err := field.parse(value, coerce)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("[%d] %s", i, err))
+			continue
+		}
+		result = parsed
+		matched = append(matched, i)
+	}
+	switch len(matched) {
+	case 1:
+		return result, nil
+	case 0:
+		return nil, issue(CodeInvalidUnion, nil, "no oneOf member matched: %s", strings.Join(errs, "; "))
+	default:
+		return nil, issue(CodeInvalidUnion, Dict{"matched": matched},
+			"expected exactly one oneOf member to match, got %d", len(matched))
+	}
+}
+
+func (o oneOfType) jsonSchema() Dict {
+	members := make([]Dict, len(o.fields))
+	for i, field := range o.fields {
+		members[i] = jsonSchemaOf(field)
+	}
+	return Dict{"oneOf": members}
+}
+
+func (NullType) Parse(value any, coerce bool) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	return nil, invalidType("null", value)
+}
+
+func (NullType) jsonSchema() Dict { return Dict{"type": "null"} }
+
+func Null() NullType { return NullType{} }
+
+func (r requiredType) parse(value any, coerce bool) (any, error) {
+	return r.field.parse(value, coerce)
+}
+
+func (r requiredType) jsonSchema() Dict { return jsonSchemaOf(r.field) }
+
+// FromJSONSchema builds a schema from a JSON Schema document whose root
+// describes an object. Supported keywords are type, properties, required,
+// enum, const, minimum, maximum, exclusiveMinimum, exclusiveMaximum,
+// minLength, maxLength, pattern, format (email, date, date-time,
+// duration), items, minItems, maxItems, oneOf, anyOf, description,
+// boolean additionalProperties and $ref to locations within the document.
+// Keywords beside $ref, enum, const, oneOf and anyOf apply as well.
+// The other applicator and validation keywords of Draft 2020-12 are
+// rejected rather than silently ignored; annotations such as title and
+// examples, and unknown formats, are ignored.
+func FromJSONSchema(document []byte) (*SchemaBuilder, error) {
+	var root map[string]any
+	if err := json.Unmarshal(document, &root); err != nil {
+		return nil, fmt.Errorf("invalid JSON Schema: %w", err)
+	}
+	im := &importer{root: Dict(root), refs: map[string]*RefType{}}
+	node, err := im.resolve(Dict(root))
+	if err != nil {
+		return nil, err
+	}
+	if !isObject(node) {
+		return nil, fmt.Errorf("invalid JSON Schema: root must describe an object")
+	}
+	// the root becomes a schema of its own, which cannot also be a
+	// union or a set of values
+	for _, keyword := range []string{"$ref", "enum", "const", "oneOf", "anyOf"} {
+		if _, ok := node[keyword]; ok {
+			return nil, fmt.Errorf("unsupported JSON Schema keyword at #: %s beside the root object", keyword)
+		}
+	}
+	if err := checkKeywords(node, "#"); err != nil {
+		return nil, err
+	}
+	schema, err := im.object(node, "#")
+	if err != nil {
+		return nil, err
+	}
+	if description, ok := node["description"].(string); ok {
+		schema.description = description
+	}
+	return schema, nil
+}
+
+// unsupported lists the Draft 2020-12 applicator and validation keywords,
+// and their earlier-draft forms, that the importer does not implement.
+var unsupported = []string{
+	"allOf", "not", "if", "then", "else", "dependentRequired", "dependentSchemas",
+	"dependencies", "patternProperties", "propertyNames", "minProperties", "maxProperties",
+	"unevaluatedProperties", "unevaluatedItems", "additionalItems", "prefixItems",
+	"uniqueItems", "contains", "minContains", "maxContains", "multipleOf",
+	"$dynamicRef", "$recursiveRef",
+}
+
+func checkKeywords(node Dict, at string) error {
+	for _, keyword := range unsupported {
+		if _, ok := node[keyword]; ok {
+			return fmt.Errorf("unsupported JSON Schema keyword at %s: %s", at, keyword)
+		}
+	}
+	return nil
+}
+
+func (im *importer) field(raw any, at string) (AnyField, error) {
+	switch v := raw.(type) {
+	case bool:
+		if v {
+			return wrap(Any()), nil
+		}
+		return Refine(Any(), func(any) error {
+			return issue(CodeInvalidType, nil, "no value is allowed")
+		}), nil
+	}
+	node, ok := asDict(raw)
+	if !ok {
+		return nil, fmt.Errorf("invalid JSON Schema at %s: expected object, got %T", at, raw)
+	}
+	if err := checkKeywords(node, at); err != nil {
+		return nil, err
+	}
+
+	field, err := im.build(node, at)
+	if err != nil {
+		return nil, err
+	}
+	if description, ok := node["description"].(string); ok {
+		field = Describe(field, description)
+	}
+	return field, nil
+}
+
+// build applies every keyword of node: $ref, enum, const, oneOf and anyOf
+// each give a field the value must match, as do the type and the keywords
+// constraining it. The typed field comes first, so that its result is
+// the one kept.
+func (im *importer) build(node Dict, at string) (AnyField, error) {
+	var fields []AnyField
+	typed, err := im.types(node, at)
+	if err != nil {
+		return nil, err
+	}
+	if typed != nil {
+		fields = append(fields, typed)
+	}
+	if ref, ok := node["$ref"].(string); ok {
+		field, err := im.ref(ref)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	if values, ok := node["enum"].([]any); ok {
+		fields = append(fields, enumOf(values))
+	}
+	if value, ok := node["const"]; ok {
+		fields = append(fields, literalOf(value))
+	}
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		members, ok := node[keyword].([]any)
+		if !ok {
+			continue
+		}
+		options := make([]AnyField, len(members))
+		for i, member := range members {
+			field, err := im.field(member, fmt.Sprintf("%s/%s/%d", at, keyword, i))
+			if err != nil {
+				return nil, err
+			}
+			options[i] = field
+		}
+		if keyword == "oneOf" {
+			fields = append(fields, oneOfType{fields: options})
+		} else {
+			fields = append(fields, wrap(UnionType{fields: options}))
+		}
+	}
+	switch len(fields) {
+	case 0:
+		return wrap(Any()), nil
+	case 1:
+		return fields[0], nil
+	default:
+		return allType{fields: fields}, nil
+	}
+}
+
+// typeKeywords lists, for each instance type, the keywords constraining
+// it. Without a type keyword they apply to instances of that type only.
+var typeKeywords = []struct {
+	typ      string
+	keywords []string
+}{
+	{"object", []string{"properties", "required", "additionalProperties"}},
+	{"array", []string{"items", "minItems", "maxItems"}},
+	{"string", []string{"minLength", "maxLength", "pattern", "format"}},
+	{"number", []string{"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum"}},
+}
+
+// types builds the field of the type keyword and the keywords constraining
+// it; nil if node has none of them.
+func (im *importer) types(node Dict, at string) (AnyField, error) {
+	var types []string
+	switch t := node["type"].(type) {
+	case string:
+		types = []string{t}
+	case []any:
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+	case nil:
+		return im.untyped(node, at)
+	default:
+		return nil, fmt.Errorf("invalid JSON Schema at %s: type must be a string or array", at)
+	}
+	if len(types) == 0 {
+		return nil, nil
+	}
+
+	fields := make([]any, len(types))
+	for i, typ := range types {
+		field, err := im.typed(typ, node, at)
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = field
+	}
+	if len(fields) == 1 {
+		return wrap(fields[0]), nil
+	}
+	return wrap(Union(fields...)), nil
+}
+
+func (im *importer) untyped(node Dict, at string) (AnyField, error) {
+	byType := map[string]AnyField{}
+	for _, group := range typeKeywords {
+		if !slices.ContainsFunc(group.keywords, func(keyword string) bool {
+			_, ok := node[keyword]
+			return ok
+		}) {
+			continue
+		}
+		field, err := im.typed(group.typ, node, at)
+		if err != nil {
+			return nil, err
+		}
+		byType[group.typ] = field
+	}
+	if len(byType) == 0 {
+		return nil, nil
+	}
+	return untypedType{byType: byType}, nil
+}
+
+func (im *importer) typed(typ string, node Dict, at string) (AnyField, error) {
+	switch typ {
+	case "string":
+		switch node["format"] {
+		case "date-time":
+			return wrap(Time()), nil
+		case "date":
+			return wrap(Date()), nil
+		case "duration":
+			return wrap(Duration()), nil
+		}
+		f := String()
+		if n, ok := intKeyword(node, "minLength"); ok {
+			f = f.Min(int(n))
+		}
+		if n, ok := intKeyword(node, "maxLength"); ok {
+			f = f.Max(int(n))
+		}
+		if node["format"] == "email" {
+			f = f.Email()
+		}
+		if pattern, ok := node["pattern"].(string); ok {
+			// JSON Schema patterns are unanchored, as are Go's
+			if _, err := regexp.Compile(pattern); err != nil {
+				return nil, fmt.Errorf("invalid JSON Schema at %s: %w", at, err)
+			}
+			f = f.Pattern(pattern)
+		}
+		return wrap(f), nil
+	case "integer":
+		f := Int()
+		// both bounds may be given; the tighter one holds
+		lo, hasLo := intKeyword(node, "minimum")
+		if n, ok := intKeyword(node, "exclusiveMinimum"); ok && (!hasLo || n > lo) {
+			lo, hasLo = n, true
+		}
+		hi, hasHi := intKeyword(node, "maximum")
+		if n, ok := intKeyword(node, "exclusiveMaximum"); ok && (!hasHi || n < hi) {
+			hi, hasHi = n, true
+		}
+		if hasLo {
+			f = f.Min(int(lo))
+		}
+		if hasHi {
+			f = f.Max(int(hi))
+		}
+		return wrap(f), nil
+	case "number":
+		f := Float()
+		if n, ok := node["minimum"].(float64); ok {
+			f = f.Min(n)
+		}
+		if n, ok := node["maximum"].(float64); ok {
+			f = f.Max(n)
+		}
+		lo, hasLo := node["exclusiveMinimum"].(float64)
+		hi, hasHi := node["exclusiveMaximum"].(float64)
+		if !hasLo && !hasHi {
+			return wrap(f), nil
+		}
+		return Refine(f, func(n float64) error {
+			if hasLo && n <= lo {
+				return issue(CodeTooSmall, Dict{"min": lo, "type": "number", "exclusive": true},
+					"expected more than %v, got %v", lo, n)
+			}
+			if hasHi && n >= hi {
+				return issue(CodeTooBig, Dict{"max": hi, "type": "number", "exclusive": true},
+					"expected less than %v, got %v", hi, n)
+			}
+			return nil
+		}), nil
+	case "boolean":
+		return wrap(Boolean()), nil
+	case "null":
+		return wrap(Null()), nil
+	case "array":
+		item := wrap(Any())
+		if items, ok := node["items"]; ok {
+			var err error
+			if item, err = im.field(items, at+"/items"); err != nil {
+				return nil, err
+			}
+		}
+		f := Array(item)
+		if n, ok := intKeyword(node, "minItems"); ok {
+			f = f.Min(int(n))
+		}
+		if n, ok := intKeyword(node, "maxItems"); ok {
+			f = f.Max(int(n))
+		}
+		return f, nil
+	case "object":
+		return im.object(node, at)
+	default:
+		return nil, fmt.Errorf("invalid JSON Schema at %s: unknown type %q", at, typ)
+	}
+}
+
+func (im *importer) object(node Dict, at string) (*SchemaBuilder, error) {
+	properties, _ := asDict(node["properties"])
+	required := map[string]bool{}
+	if list, ok := node["required"].([]any); ok {
+		for _, name := range list {
+			if s, ok := name.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+	fields := make(Fields, len(properties))
+	for name, property := range properties {
+		field, err := im.field(property, at+"/properties/"+escapePointer(name))
+		if err != nil {
+			return nil, err
+		}
+		if required[name] {
+			fields[name] = requiredType{field: field}
+		} else {
+			fields[name] = Optional(field)
+		}
+	}
+	for name := range required {
+		if _, ok := fields[name]; !ok {
+			fields[name] = requiredType{field: wrap(Any())}
+		}
+	}
+	schema := &SchemaBuilder{fields: fields}
+	switch additional := node["additionalProperties"].(type) {
+	case nil:
+	case bool:
+		if additional {
+			schema.unknown = unknownPassthrough
+		} else {
+			schema.unknown = unknownStrict
+		}
+	default:
+		return nil, fmt.Errorf("unsupported JSON Schema keyword at %s: additionalProperties with a schema", at)
+	}
+	return schema, nil
+}
+
+func (im *importer) ref(ref string) (AnyField, error) {
+	if r, ok := im.refs[ref]; ok {
+		return r, nil
+	}
+	target, err := im.pointer(ref)
+	if err != nil {
+		return nil, err
+	}
+	r := &RefType{ref: ref}
+	im.refs[ref] = r
+	field, err := im.field(target, ref)
+	if err != nil {
+		return nil, err
+	}
+	r.field = field
+	return r, nil
+}
+
+// rootAnnotations lists the keywords that may sit beside a $ref at the
+// document root without constraining it.
+var rootAnnotations = []string{
+	"$ref", "$schema", "$id", "$defs", "definitions", "$comment",
+	"title", "description", "examples", "default",
+}
+
+// resolve follows a $ref at the document root, for documents that point
+// straight into their own $defs.
+func (im *importer) resolve(node Dict) (Dict, error) {
+	seen := map[string]bool{}
+	for {
+		ref, ok := node["$ref"].(string)
+		if !ok {
+			return node, nil
+		}
+		if seen[ref] {
+			return nil, fmt.Errorf("invalid JSON Schema: circular $ref %s", ref)
+		}
+		for keyword := range node {
+			if !slices.Contains(rootAnnotations, keyword) {
+				return nil, fmt.Errorf("unsupported JSON Schema keyword at #: %s beside a root $ref", keyword)
+			}
+		}
+		seen[ref] = true
+		target, err := im.pointer(ref)
+		if err != nil {
+			return nil, err
+		}
+		if node, ok = asDict(target); !ok {
+			return nil, fmt.Errorf("invalid JSON Schema: %s is not an object", ref)
+		}
+	}
+}
+
+func (im *importer) pointer(ref string) (any, error) {
+	path, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return nil, fmt.Errorf("unsupported $ref %s: only references within the document are supported", ref)
+	}
+	var node any = im.root
+	if path == "" {
+		return node, nil
+	}
+	for token := range strings.SplitSeq(strings.TrimPrefix(path, "/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch n := node.(type) {
+		case Dict:
+			node = n[token]
+		case map[string]any:
+			node = n[token]
+		case []any:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, fmt.Errorf("unresolvable $ref %s", ref)
+			}
+			node = n[i]
+		default:
+			node = nil
+		}
+		if node == nil {
+			return nil, fmt.Errorf("unresolvable $ref %s", ref)
+		}
+	}
+	return node, nil
+}
+
+func enumOf(values []any) AnyField {
+	strs := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	if len(strs) == len(values) {
+		return wrap(Enum(strs...))
+	}
+	fields := make([]any, len(values))
+	for i, v := range values {
+		fields[i] = literalOf(v)
+	}
+	return wrap(Union(fields...))
+}
+
+func literalOf(value any) AnyField {
+	if value == nil {
+		return wrap(Null())
+	}
+	return wrap(Literal(value))
+}
+
+func isObject(node Dict) bool {
+	switch t := node["type"].(type) {
+	case string:
+		return t == "object"
+	case nil:
+		_, ok := node["properties"]
+		return ok
+	default:
+		return false
+	}
+}
+
+func intKeyword(node Dict, keyword string) (int64, bool) {
+	f, ok := node[keyword].(float64)
+	if !ok {
+		return 0, false
+	}
+	// integer bounds are tightened to the nearest integer inside the range
+	switch keyword {
+	case "minimum":
+		f = math.Ceil(f)
+	case "exclusiveMinimum":
+		f = math.Floor(f) + 1
+	case "exclusiveMaximum":
+		f = math.Ceil(f) - 1
+	default:
+		f = math.Floor(f)
+	}
+	return int64(f), true
+}
+
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,189 @@
+//go:build js && wasm
+
+package pema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func fromJSONSchema(t *testing.T, document string) *SchemaBuilder {
+	t.Helper()
+	schema, err := FromJSONSchema([]byte(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+func TestFromJSONSchemaSiblings(t *testing.T) {
+	schema := fromJSONSchema(t, `{
+		"type": "object",
+		"$defs": {"n": {"type": "number"}},
+		"properties": {
+			"ref": {"$ref": "#/$defs/n", "minimum": 5},
+			"shape": {
+				"properties": {"kind": {"type": "string"}, "size": {"type": "integer"}},
+				"required": ["kind"],
+				"oneOf": [{"required": ["size"]}, {"required": ["label"]}]
+			},
+			"code": {"enum": ["a", "bb", 3], "type": "string", "minLength": 2}
+		}
+	}`)
+	tests := []struct {
+		data Dict
+		want []string
+	}{
+		{Dict{"ref": 7.0}, nil},
+		{Dict{"ref": 1.0}, []string{"ref:too_small"}},
+		{Dict{"ref": "7"}, []string{"ref:invalid_type"}},
+		{Dict{"shape": Dict{"kind": "box", "size": 2.0}}, nil},
+		{Dict{"shape": Dict{"kind": "box", "label": "x"}}, nil},
+		{Dict{"shape": Dict{"size": 2.0}}, []string{"shape.kind:required"}},
+		{Dict{"shape": Dict{"kind": "box"}}, []string{"shape:invalid_union"}},
+		{Dict{"shape": Dict{"kind": "box", "size": 2.0, "label": "x"}}, []string{"shape:invalid_union"}},
+		{Dict{"shape": Dict{"kind": 1.0, "size": 2.0}}, []string{"shape.kind:invalid_type"}},
+		{Dict{"code": "bb"}, nil},
+		{Dict{"code": "a"}, []string{"code:too_small"}},
+		{Dict{"code": 3.0}, []string{"code:invalid_type"}},
+	}
+	for _, test := range tests {
+		_, err := schema.Parse(test.data)
+		if got := codes(err); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: got %v, want %v", test.data, got, test.want)
+		}
+	}
+
+	got, err := schema.Parse(Dict{"shape": Dict{"kind": "box", "size": 2.0, "extra": true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Dict{"kind": "box", "size": 2}); !reflect.DeepEqual(got["shape"], want) {
+		t.Errorf("got %#v, want %#v", got["shape"], want)
+	}
+}
+
+func TestFromJSONSchemaUntyped(t *testing.T) {
+	schema := fromJSONSchema(t, `{
+		"properties": {
+			"value": {"minLength": 2, "minimum": 1},
+			"member": {"anyOf": [{"required": ["a"]}, {"type": "string"}]}
+		}
+	}`)
+	tests := []struct {
+		data Dict
+		want []string
+	}{
+		{Dict{"value": "ab"}, nil},
+		{Dict{"value": 2.0}, nil},
+		{Dict{"value": true}, nil},
+		{Dict{"value": "a"}, []string{"value:too_small"}},
+		{Dict{"value": 0.0}, []string{"value:too_small"}},
+		{Dict{"member": Dict{"a": 1.0}}, nil},
+		{Dict{"member": "x"}, nil},
+		{Dict{"member": Dict{"b": 1.0}}, []string{"member:invalid_union"}},
+	}
+	for _, test := range tests {
+		_, err := schema.Parse(test.data)
+		if got := codes(err); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: got %v, want %v", test.data, got, test.want)
+		}
+	}
+}
+
+func TestFromJSONSchemaErrors(t *testing.T) {
+	for _, document := range []string{
+		`[]`,
+		`{"type": "string"}`,
+		`{"type": "object", "allOf": []}`,
+		`{"type": "object", "properties": {"a": {"not": {}}}}`,
+		`{"type": "object", "oneOf": [{"required": ["a"]}]}`,
+		`{"$ref": "#/$defs/o", "required": ["a"], "$defs": {"o": {"type": "object"}}}`,
+		`{"type": "object", "properties": {"a": {"$ref": "#/$defs/missing"}}}`,
+	} {
+		if _, err := FromJSONSchema([]byte(document)); err == nil {
+			t.Errorf("%s: accepted", document)
+		}
+	}
+}
+
+func TestFromJSONSchemaStructuredValues(t *testing.T) {
+	schema := fromJSONSchema(t, `{
+		"type": "object",
+		"properties": {
+			"point": {"const": {"x": 1, "y": [2, 3]}},
+			"pair": {"enum": [[1, 2], {"a": null}, "none"]}
+		}
+	}`)
+	tests := []struct {
+		data Dict
+		want []string
+	}{
+		{Dict{"point": Dict{"x": 1.0, "y": []any{2.0, 3.0}}}, nil},
+		{Dict{"point": map[string]any{"x": 1, "y": []any{2, int64(3)}}}, nil},
+		{Dict{"point": Dict{"x": 1.0, "y": []any{3.0, 2.0}}}, []string{"point:invalid_literal"}},
+		{Dict{"point": Dict{"x": 1.0}}, []string{"point:invalid_literal"}},
+		{Dict{"pair": []any{1.0, 2.0}}, nil},
+		{Dict{"pair": Dict{"a": nil}}, nil},
+		{Dict{"pair": "none"}, nil},
+		{Dict{"pair": []any{1.0}}, []string{"pair:invalid_union"}},
+	}
+	for _, test := range tests {
+		_, err := schema.Parse(test.data)
+		if got := codes(err); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: got %v, want %v", test.data, got, test.want)
+		}
+	}
+}
+
+func TestRecursiveJSONSchema(t *testing.T) {
+	schema := fromJSONSchema(t, `{
+		"type": "object",
+		"properties": {"tree": {"$ref": "#/$defs/tree"}, "leaf": {"$ref": "#/$defs/leaf"}},
+		"$defs": {
+			"leaf": {"type": "string"},
+			"tree": {
+				"type": "object",
+				"properties": {"children": {"type": "array", "items": {"$ref": "#/$defs/tree"}}}
+			}
+		}
+	}`)
+	document := schema.JSONSchema()
+	delete(document, "$schema")
+	equalJSON(t, document, `{
+		"type": "object",
+		"properties": {
+			"tree": {"anyOf": [{"$ref": "#/$defs/tree"}, {"type": "null"}]},
+			"leaf": {"type": ["string", "null"]}
+		},
+		"$defs": {
+			"tree": {
+				"type": "object",
+				"properties": {"children": {"type": ["array", "null"], "items": {"$ref": "#/$defs/tree"}}}
+			}
+		}
+	}`)
+
+	document = Struct[node]().JSONSchema()
+	delete(document, "$schema")
+	equalJSON(t, document, `{
+		"type": "object",
+		"required": ["children", "value"],
+		"properties": {
+			"value": {"type": "integer"},
+			"children": {"type": "array", "items": {"$ref": "#/$defs/pema.node"}},
+			"next": {"anyOf": [{"$ref": "#/$defs/pema.node"}, {"type": "null"}]}
+		},
+		"$defs": {
+			"pema.node": {
+				"type": "object",
+				"required": ["children", "value"],
+				"properties": {
+					"value": {"type": "integer"},
+					"children": {"type": "array", "items": {"$ref": "#/$defs/pema.node"}},
+					"next": {"anyOf": [{"$ref": "#/$defs/pema.node"}, {"type": "null"}]}
+				}
+			}
+		}
+	}`)
+}
@@ -0,0 +1,307 @@
+//go:build js && wasm
+
+package pema
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const draft = "https://json-schema.org/draft/2020-12/schema"
+
+// schemer is implemented by fields that can describe themselves as JSON
+// Schema.
+type schemer interface {
+	jsonSchema() Dict
+}
+
+type DescribeType struct {
+	field       AnyField
+	description string
+}
+
+func (d DescribeType) parse(value any, coerce bool) (any, error) {
+	return d.field.parse(value, coerce)
+}
+
+func (d DescribeType) unwrap() any { return d.field }
+
+// Describe attaches a description to field, emitted by JSONSchema.
+func Describe(field any, description string) DescribeType {
+	return DescribeType{field: wrap(field), description: description}
+}
+
+// Describe returns a copy of the schema carrying description.
+func (s *SchemaBuilder) Describe(description string) *SchemaBuilder {
+	clone := s.clone()
+	clone.description = description
+	return clone
+}
+
+// JSONSchema returns the schema as a JSON Schema (draft 2020-12) document.
+func (s *SchemaBuilder) JSONSchema() Dict {
+	document := s.jsonSchema()
+	defs := Dict{}
+	hoistDefs(document, defs)
+	if len(defs) > 0 {
+		document["$defs"] = defs
+	}
+	document["$schema"] = draft
+	return document
+}
+
+func jsonSchemaOf(field any) Dict {
+	if s, ok := field.(schemer); ok {
+		return s.jsonSchema()
+	}
+	return Dict{}
+}
+
+func (s *SchemaBuilder) jsonSchema() Dict {
+	properties := Dict{}
+	for name, field := range s.fields {
+		properties[name] = jsonSchemaOf(field)
+	}
+	schema := Dict{"type": "object", "properties": properties}
+	if required := s.RequiredKeys(); len(required) > 0 {
+		schema["required"] = required
+	}
+	if s.description != "" {
+		schema["description"] = s.description
+	}
+	if s.unknown == unknownStrict {
+		schema["additionalProperties"] = false
+	}
+	return schema
+}
+
+func (w fieldWrapper[T]) jsonSchema() Dict { return jsonSchemaOf(w.field) }
+
+func (d DescribeType) jsonSchema() Dict {
+	schema := jsonSchemaOf(d.field)
+	schema["description"] = d.description
+	return schema
+}
+
+func (t StringType) jsonSchema() Dict {
+	schema := Dict{"type": "string"}
+	if t.min != nil {
+		schema["minLength"] = *t.min
+	}
+	if t.max != nil {
+		schema["maxLength"] = *t.max
+	}
+	if t.email {
+		schema["format"] = "email"
+	}
+	if t.pattern != nil {
+		schema["pattern"] = t.pattern.String()
+	}
+	return schema
+}
+
+func (BooleanType) jsonSchema() Dict { return Dict{"type": "boolean"} }
+
+func (t IntType) jsonSchema() Dict { return numberSchema("integer", t.min, t.max) }
+
+func (t Int64Type) jsonSchema() Dict { return numberSchema("integer", t.min, t.max) }
+
+func (t FloatType) jsonSchema() Dict { return numberSchema("number", t.min, t.max) }
+
+func numberSchema[N int | int64 | float64](typ string, min, max *N) Dict {
+	schema := Dict{"type": typ}
+	if min != nil {
+		schema["minimum"] = *min
+	}
+	if max != nil {
+		schema["maximum"] = *max
+	}
+	return schema
+}
+
+func (e EnumType) jsonSchema() Dict {
+	return Dict{"type": "string", "enum": slices.Clone(e.values)}
+}
+
+func (l LiteralType[T]) jsonSchema() Dict { return Dict{"const": l.value} }
+
+func (u UnionType) jsonSchema() Dict {
+	members := make([]Dict, len(u.fields))
+	for i, field := range u.fields {
+		members[i] = jsonSchemaOf(field)
+	}
+	return Dict{"anyOf": members}
+}
+
+func (t TaggedType) jsonSchema() Dict {
+	var variants []Dict
+	for _, tag := range slices.Sorted(maps.Keys(t.variants)) {
+		variant := t.variants[tag].jsonSchema()
+		variant["properties"].(Dict)[t.key] = Dict{"const": tag}
+		required, _ := variant["required"].([]string)
+		if !slices.Contains(required, t.key) {
+			variant["required"] = append(required, t.key)
+		}
+		variants = append(variants, variant)
+	}
+	return Dict{"oneOf": variants}
+}
+
+// Before and After have no JSON Schema keyword for strings; they are
+// emitted as formatExclusiveMaximum/formatExclusiveMinimum, which
+// validators supporting format comparison (such as ajv-formats) honour.
+func (t TimeType) jsonSchema() Dict {
+	var schema Dict
+	switch {
+	case t.unit != 0:
+		schema = Dict{"type": "number"}
+		epoch := func(limit time.Time) int64 {
+			if t.unit == time.Millisecond {
+				return limit.UnixMilli()
+			}
+			return limit.Unix()
+		}
+		if t.before != nil {
+			schema["exclusiveMaximum"] = epoch(*t.before)
+		}
+		if t.after != nil {
+			schema["exclusiveMinimum"] = epoch(*t.after)
+		}
+		return schema
+	case slices.Equal(t.layouts, []string{time.DateOnly}):
+		schema = Dict{"type": "string", "format": "date"}
+	case slices.Equal(t.layouts, []string{time.RFC3339Nano}):
+		schema = Dict{"type": "string", "format": "date-time"}
+	default:
+		schema = Dict{"type": "string"}
+	}
+	if t.before != nil {
+		schema["formatExclusiveMaximum"] = t.before.Format(t.layouts[0])
+	}
+	if t.after != nil {
+		schema["formatExclusiveMinimum"] = t.after.Format(t.layouts[0])
+	}
+	return schema
+}
+
+func (d DurationType) jsonSchema() Dict {
+	schema := Dict{"type": "string", "format": "duration"}
+	if d.min != nil {
+		schema["formatMinimum"] = isoDuration(*d.min)
+	}
+	if d.max != nil {
+		schema["formatMaximum"] = isoDuration(*d.max)
+	}
+	return schema
+}
+
+func isoDuration(d time.Duration) string {
+	return "PT" + strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "S"
+}
+
+// Optional fields also accept null.
+func (o OptionalType) jsonSchema() Dict {
+	schema := jsonSchemaOf(o.field)
+	_, enum := schema["enum"]
+	_, constant := schema["const"]
+	if t, ok := schema["type"].(string); ok && !enum && !constant {
+		schema["type"] = []string{t, "null"}
+		return schema
+	}
+	if len(schema) == 0 {
+		// anything, null included
+		return schema
+	}
+	return Dict{"anyOf": []Dict{schema, {"type": "null"}}}
+}
+
+func (a ArrayType) jsonSchema() Dict {
+	schema := Dict{"type": "array", "items": jsonSchemaOf(a.item)}
+	if a.min != nil {
+		schema["minItems"] = *a.min
+	}
+	if a.max != nil {
+		schema["maxItems"] = *a.max
+	}
+	return schema
+}
+
+func (AnyType) jsonSchema() Dict { return Dict{} }
+
+func (r RefineType[T]) jsonSchema() Dict { return jsonSchemaOf(r.field) }
+
+func (t TransformType[T, U]) jsonSchema() Dict { return jsonSchemaOf(t.field) }
+
+func (p PreprocessType[T]) jsonSchema() Dict { return jsonSchemaOf(p.field) }
+
+// jsonSchema expands the reference in place unless it refers to itself;
+// then the expansion becomes a definition, which JSONSchema moves to the
+// document's $defs, and every use a $ref to it.
+func (r *RefType) jsonSchema() Dict {
+	ref := Dict{"$ref": "#/$defs/" + r.name()}
+	if r.expanding {
+		r.recursive = true
+		return ref
+	}
+	r.expanding = true
+	schema := jsonSchemaOf(r.field)
+	r.expanding = false
+	if !r.recursive {
+		return schema
+	}
+	r.recursive = false
+	ref["$defs"] = Dict{r.name(): schema}
+	return ref
+}
+
+// name is the reference's key in $defs: the name it is defined under, or
+// its location, in the characters that need no escaping.
+func (r *RefType) name() string {
+	name := r.ref
+	for _, prefix := range []string{"#/$defs/", "#/definitions/", "#/", "#"} {
+		if trimmed, ok := strings.CutPrefix(name, prefix); ok {
+			name = trimmed
+			break
+		}
+	}
+	name = strings.Map(func(c rune) rune {
+		switch {
+		case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '-' || c == '_':
+			return c
+		default:
+			return '_'
+		}
+	}, name)
+	if name == "" {
+		return "root"
+	}
+	return name
+}
+
+// hoistDefs moves every $defs below schema into defs.
+func hoistDefs(schema any, defs Dict) {
+	switch s := schema.(type) {
+	case Dict:
+		if nested, ok := s["$defs"].(Dict); ok {
+			delete(s, "$defs")
+			maps.Copy(defs, nested)
+			for _, def := range nested {
+				hoistDefs(def, defs)
+			}
+		}
+		for _, value := range s {
+			hoistDefs(value, defs)
+		}
+	case []Dict:
+		for _, item := range s {
+			hoistDefs(item, defs)
+		}
+	case []any:
+		for _, item := range s {
+			hoistDefs(item, defs)
+		}
+	}
+}
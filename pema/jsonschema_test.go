@@ -0,0 +1,107 @@
+//go:build js && wasm
+
+package pema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseAbsent(t *testing.T) {
+	schema := Schema(map[string]any{
+		"page":     Int(),
+		"name":     String(),
+		"nick":     String().Min(1),
+		"note":     Optional(String()),
+		"avatar":   File(),
+		"photos":   File().Multiple(1, 0),
+		"extras":   File().Multiple(0, 3),
+		"optional": Optional(File()),
+	})
+	tests := []struct {
+		coerce bool
+		want   []string
+	}{
+		{false, []string{"avatar:required", "nick:too_small", "page:invalid_type", "photos:required"}},
+		{true, []string{"avatar:required", "nick:too_small", "photos:required"}},
+	}
+	for _, test := range tests {
+		_, err := schema.Parse(Dict{}, test.coerce)
+		if got := codes(err); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("coerce %t: got %v, want %v", test.coerce, got, test.want)
+		}
+	}
+
+	file := UploadFile{Field: "avatar", Name: "a.png", Type: "image/png"}
+	got, err := schema.Parse(Dict{"nick": "n", "avatar": file, "photos": file}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Dict{"page": 0, "name": "", "nick": "n", "avatar": file, "photos": []UploadFile{file}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRequiredKeys(t *testing.T) {
+	schema := Schema(map[string]any{
+		"page":   Int(),
+		"name":   String(),
+		"nick":   String().Min(1),
+		"note":   Optional(String()),
+		"avatar": File(),
+		"extras": File().Multiple(0, 3),
+		"tags":   Array(String()),
+	}).Required("name")
+	if got, want := schema.RequiredKeys(), []string{"avatar", "name", "nick", "page", "tags"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, want := schema.RequiredKeys(true), []string{"avatar", "name", "nick", "tags"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := schema.JSONSchema()["required"]; !reflect.DeepEqual(got, schema.RequiredKeys()) {
+		t.Errorf("got required %v, want %v", got, schema.RequiredKeys())
+	}
+}
+
+// equalJSON compares got and want as JSON, so that Dict and []Dict
+// compare equal to their decoded forms.
+func equalJSON(t *testing.T, got any, want string) {
+	t.Helper()
+	raw, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var g, w any
+	json.Unmarshal(raw, &g)
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("got %s, want %s", raw, want)
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		field any
+		want  string
+	}{
+		{String().Min(1).Max(5), `{"type":"string","minLength":1,"maxLength":5}`},
+		{Optional(Int().Min(1)), `{"type":["integer","null"],"minimum":1}`},
+		{Optional(Enum("a", "b")), `{"anyOf":[{"type":"string","enum":["a","b"]},{"type":"null"}]}`},
+		{Optional(Any()), `{}`},
+		{Describe(Optional(Boolean()), "flag"), `{"type":["boolean","null"],"description":"flag"}`},
+		{Unix().After(day), `{"type":"number","exclusiveMinimum":1704153600}`},
+		{UnixMilli().Before(day), `{"type":"number","exclusiveMaximum":1704153600000}`},
+		{Date().After(day), `{"type":"string","format":"date","formatExclusiveMinimum":"2024-01-02"}`},
+		{Duration().Min(90 * time.Second), `{"type":"string","format":"duration","formatMinimum":"PT90S"}`},
+		{Array(Int()).Min(1), `{"type":"array","items":{"type":"integer"},"minItems":1}`},
+	}
+	for _, test := range tests {
+		equalJSON(t, jsonSchemaOf(wrap(test.field)), test.want)
+	}
+}
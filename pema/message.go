@@ -0,0 +1,84 @@
+//go:build js && wasm
+
+package pema
+
+import "sync"
+
+// Resolver renders an issue as a message, for instance by translating
+// Key with Vars. Returning "" keeps the default English message.
+type Resolver func(Issue) string
+
+var (
+	resolverMu sync.RWMutex
+	resolver   Resolver
+)
+
+// Messages holds the English template for each issue key, in the {var}
+// syntax of primate locale files, as a starting point for translations.
+var Messages = map[string]string{
+	"pema.required":              "{path} is required",
+	"pema.invalid_type":          "expected {expected}, got {received}",
+	"pema.invalid_string":        "invalid {validation}",
+	"pema.invalid_enum":          "expected one of {options}",
+	"pema.invalid_literal":       "expected {expected}",
+	"pema.invalid_union":         "{message}",
+	"pema.invalid_discriminator": "expected one of {options}",
+	"pema.invalid_date":          "invalid date",
+	"pema.invalid_duration":      "invalid duration",
+	"pema.invalid_file_type":     "expected one of {options}",
+	"pema.not_integer":           "expected integer",
+	"pema.too_small":             "must be at least {min}",
+	"pema.too_big":               "must be at most {max}",
+	"pema.unrecognized_keys":     "unrecognized keys {keys}",
+	"pema.custom":                "{message}",
+}
+
+// SetResolver installs r to render the messages of issues reported by
+// SchemaBuilder.Parse; nil restores the default messages.
+func SetResolver(r Resolver) {
+	resolverMu.Lock()
+	defer resolverMu.Unlock()
+	resolver = r
+}
+
+// Key is the message key for the issue, "pema." followed by its code, as
+// in pema.too_small.
+func (i Issue) Key() string { return "pema." + i.Code }
+
+// Vars are the values a message for the issue may interpolate: its params
+// plus path and the default message.
+func (i Issue) Vars() Dict {
+	vars := Dict{"path": i.Path, "message": i.Message}
+	for k, v := range i.Params {
+		switch v := v.(type) {
+		case []string:
+			vars[k] = quoteAll(v)
+		default:
+			vars[k] = v
+		}
+	}
+	return vars
+}
+
+// Issues returns the issues carried by err, which is typically a
+// *ParseError returned from SchemaBuilder.Parse.
+func Issues(err error) []Issue {
+	if err == nil {
+		return nil
+	}
+	return issues("", err)
+}
+
+func localize(failed []Issue) {
+	resolverMu.RLock()
+	r := resolver
+	resolverMu.RUnlock()
+	if r == nil {
+		return
+	}
+	for i := range failed {
+		if message := r(failed[i]); message != "" {
+			failed[i].Message = message
+		}
+	}
+}
@@ -3,8 +3,16 @@
 package pema
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
 	"strconv"
+	"time"
 
 	"github.com/primate-run/go/types"
 )
@@ -15,20 +23,38 @@ type Field[T any] interface {
 	Parse(value any, coerce bool) (T, error)
 }
 
-type StringType struct{}
+type StringType struct {
+	min     *int
+	max     *int
+	email   bool
+	pattern *regexp.Regexp
+}
+
 type BooleanType struct{}
-type IntType struct{}
-type Int64Type struct{}
-type FloatType struct{}
 
-func (StringType) Parse(value any, coerce bool) (string, error) {
+type IntType struct {
+	min *int
+	max *int
+}
+
+type Int64Type struct {
+	min *int64
+	max *int64
+}
+
+type FloatType struct {
+	min *float64
+	max *float64
+}
+
+func (StringType) scalar(value any, coerce bool) (string, error) {
 	if s, ok := value.(string); ok {
 		return s, nil
 	}
 	if coerce {
 		return fmt.Sprintf("%v", value), nil
 	}
-	return "", fmt.Errorf("expected string, got %T", value)
+	return "", invalidType("string", value)
 }
 
 func (BooleanType) Parse(value any, coerce bool) (bool, error) {
@@ -41,90 +67,196 @@ func (BooleanType) Parse(value any, coerce bool) (bool, error) {
 			if v == "" {
 				return false, nil
 			}
-			return strconv.ParseBool(v)
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return false, issue(CodeInvalidType, Dict{"expected": "boolean", "received": v},
+					"cannot parse '%s' as boolean", v)
+			}
+			return b, nil
 		default:
-			return false, fmt.Errorf("cannot coerce %T to boolean", value)
+			return false, invalidType("boolean", value)
 		}
 	}
-	return false, fmt.Errorf("expected boolean, got %T", value)
+	return false, invalidType("boolean", value)
 }
 
-func (IntType) Parse(value any, coerce bool) (int, error) {
+func (IntType) scalar(value any, coerce bool) (int, error) {
 	if i, ok := value.(int); ok {
 		return i, nil
 	}
+	if i, ok, err := integer(value, math.MinInt, math.MaxInt); ok {
+		return int(i), err
+	}
 	if coerce {
 		switch v := value.(type) {
-		case float64:
-			return int(v), nil
 		case string:
 			if v == "" {
 				return 0, nil
 			}
 			i, err := strconv.Atoi(v)
 			if err != nil {
-				return 0, fmt.Errorf("cannot parse '%s' as integer", v)
+				return 0, issue(CodeInvalidType, Dict{"expected": "integer", "received": v},
+					"cannot parse '%s' as integer", v)
 			}
 			return i, nil
 		default:
-			return 0, fmt.Errorf("cannot coerce %T to int", value)
+			return 0, invalidType("integer", value)
 		}
 	}
 
-	return 0, fmt.Errorf("expected int, got %T", value)
+	return 0, invalidType("integer", value)
 }
 
-func (Int64Type) Parse(value any, coerce bool) (int64, error) {
+func (Int64Type) scalar(value any, coerce bool) (int64, error) {
 	if i, ok := value.(int64); ok {
 		return i, nil
 	}
+	if i, ok, err := integer(value, math.MinInt64, math.MaxInt64); ok {
+		return i, err
+	}
 	if coerce {
 		switch v := value.(type) {
-		case int:
-			return int64(v), nil
-		case float64:
-			return int64(v), nil
 		case string:
 			if v == "" {
 				return int64(0), nil
 			}
-			return strconv.ParseInt(v, 10, 64)
+			i, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return 0, issue(CodeInvalidType, Dict{"expected": "integer", "received": v},
+					"cannot parse '%s' as integer", v)
+			}
+			return i, nil
 		default:
-			return 0, fmt.Errorf("cannot coerce %T to int64", value)
+			return 0, invalidType("integer", value)
 		}
 	}
-	return 0, fmt.Errorf("expected int64, got %T", value)
+	return 0, invalidType("integer", value)
 }
 
-func (FloatType) Parse(value any, coerce bool) (float64, error) {
+// integer accepts the numeric representations JSON decoding produces
+// (float64, json.Number) as well as Go integers, provided the value is
+// integral and within [min, max]. ok reports whether value was numeric.
+func integer(value any, min, max int64) (i int64, ok bool, err error) {
+	switch v := value.(type) {
+	case int:
+		i = int64(v)
+	case int32:
+		i = int64(v)
+	case int64:
+		i = v
+	case uint64:
+		if v > math.MaxInt64 {
+			return 0, true, issue(CodeTooBig, Dict{"max": max, "type": "number"},
+				"expected at most %d, got %d", max, v)
+		}
+		i = int64(v)
+	case float64:
+		if i, err = fromFloat(v, min, max); err != nil {
+			return 0, true, err
+		}
+	case json.Number:
+		if n, perr := v.Int64(); perr == nil {
+			i = n
+			break
+		}
+		f, perr := v.Float64()
+		if perr != nil && !isRangeErr(perr) {
+			return 0, true, issue(CodeInvalidType, Dict{"expected": "integer", "received": string(v)},
+				"cannot parse '%s' as integer", v)
+		}
+		if i, err = fromFloat(f, min, max); err != nil {
+			return 0, true, err
+		}
+	default:
+		return 0, false, nil
+	}
+	if err := bounds(i, &min, &max); err != nil {
+		return 0, true, err
+	}
+	return i, true, nil
+}
+
+func fromFloat(f float64, min, max int64) (int64, error) {
+	if math.IsNaN(f) || (!math.IsInf(f, 0) && f != math.Trunc(f)) {
+		return 0, issue(CodeNotInteger, Dict{"received": f}, "expected integer, got %v", f)
+	}
+	// float64(math.MaxInt64) rounds up to 2^63, so compare against it directly
+	if f < -0x1p63 {
+		return 0, issue(CodeTooSmall, Dict{"min": min, "type": "number"},
+			"expected at least %d, got %v", min, f)
+	}
+	if f >= 0x1p63 {
+		return 0, issue(CodeTooBig, Dict{"max": max, "type": "number"},
+			"expected at most %d, got %v", max, f)
+	}
+	return int64(f), nil
+}
+
+func isRangeErr(err error) bool {
+	var numErr *strconv.NumError
+	return errors.As(err, &numErr) && errors.Is(numErr.Err, strconv.ErrRange)
+}
+
+func (FloatType) scalar(value any, coerce bool) (float64, error) {
 	if f, ok := value.(float64); ok {
 		return f, nil
 	}
+	if n, ok := value.(json.Number); ok {
+		f, err := n.Float64()
+		if err != nil {
+			return 0.0, issue(CodeInvalidType, Dict{"expected": "number", "received": string(n)},
+				"cannot parse '%s' as float", n)
+		}
+		return f, nil
+	}
+	// binary formats decode integral numbers as integers
+	if f, ok := number(value); ok {
+		return f, nil
+	}
 	if coerce {
 		switch v := value.(type) {
-		case int:
-			return float64(v), nil
 		case string:
 			if v == "" {
 				return 0.0, nil
 			}
 			f, err := strconv.ParseFloat(v, 64)
 			if err != nil {
-				return 0.0, fmt.Errorf("cannot parse '%s' as float", v)
+				return 0.0, issue(CodeInvalidType, Dict{"expected": "number", "received": v},
+					"cannot parse '%s' as float", v)
 			}
 			return f, nil
 		default:
-			return 0.0, fmt.Errorf("cannot coerce %T to float", value)
+			return 0.0, invalidType("number", value)
 		}
 	}
-	return 0.0, fmt.Errorf("expected float64, got %T", value)
+	return 0.0, invalidType("number", value)
 }
 
-func String() Field[string] { return StringType{} }
-func Boolean() Field[bool]  { return BooleanType{} }
-func Int() Field[int]       { return IntType{} }
-func Int64() Field[int64]   { return Int64Type{} }
-func Float() Field[float64] { return FloatType{} }
+func number(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func String() StringType   { return StringType{} }
+func Boolean() BooleanType { return BooleanType{} }
+func Int() IntType         { return IntType{} }
+func Int64() Int64Type     { return Int64Type{} }
+func Float() FloatType     { return FloatType{} }
 
 type AnyField interface {
 	parse(value any, coerce bool) (any, error)
@@ -138,29 +270,194 @@ func (w fieldWrapper[T]) parse(value any, coerce bool) (any, error) {
 	return w.field.Parse(value, coerce)
 }
 
+func (w fieldWrapper[T]) unwrap() any { return w.field }
+
+// wrapper is implemented by fields that wrap another field, so that an
+// Optional inside Refine, Transform, Preprocess or Describe still makes
+// the field optional.
+type wrapper interface {
+	unwrap() any
+}
+
+func unwrapped(field any) any {
+	for {
+		w, ok := field.(wrapper)
+		if !ok {
+			return field
+		}
+		field = w.unwrap()
+	}
+}
+
+// optional reports whether field may be absent.
+func optional(field any) bool {
+	switch f := unwrapped(field).(type) {
+	case OptionalType:
+		return true
+	case FilesType:
+		return f.min == 0
+	default:
+		return false
+	}
+}
+
+// absent decides what Parse makes of a field whose key is missing: it is
+// skipped when optional and an issue when required. Other fields parse
+// "", as an empty form value would.
+func absent(field AnyField, coerce bool) (value any, skip bool, err error) {
+	if optional(field) {
+		return nil, true, nil
+	}
+	switch unwrapped(field).(type) {
+	case requiredType:
+		return nil, false, issue(CodeRequired, nil, "missing required field")
+	case FileType, FilesType:
+		return nil, false, issue(CodeRequired, nil, "missing required file")
+	}
+	value, err = field.parse("", coerce)
+	return value, false, err
+}
+
+type OptionalType struct {
+	field AnyField
+}
+
+// RefType is a field filled in after construction, so that recursive
+// schemas can refer to themselves.
+type RefType struct {
+	ref       string
+	field     AnyField
+	expanding bool
+	recursive bool
+}
+
+type ArrayType struct {
+	item AnyField
+	min  *int
+	max  *int
+}
+
+type AnyType struct{}
+
+func (o OptionalType) Parse(value any, coerce bool) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	return o.field.parse(value, coerce)
+}
+
+func (o OptionalType) parse(value any, coerce bool) (any, error) {
+	return o.Parse(value, coerce)
+}
+
+func (r *RefType) parse(value any, coerce bool) (any, error) {
+	return r.field.parse(value, coerce)
+}
+
+func (a ArrayType) Parse(value any, coerce bool) ([]any, error) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, invalidType("array", value)
+	}
+	n := v.Len()
+	if a.min != nil && n < *a.min {
+		return nil, issue(CodeTooSmall, Dict{"min": *a.min, "type": "array"},
+			"expected at least %d items, got %d", *a.min, n)
+	}
+	if a.max != nil && n > *a.max {
+		return nil, issue(CodeTooBig, Dict{"max": *a.max, "type": "array"},
+			"expected at most %d items, got %d", *a.max, n)
+	}
+	result := make([]any, n)
+	var failed []Issue
+	for i := range n {
+		parsed, err := a.item.parse(v.Index(i).Interface(), coerce)
+		if err != nil {
+			failed = append(failed, issues(strconv.Itoa(i), err)...)
+			continue
+		}
+		result[i] = parsed
+	}
+	if failed != nil {
+		return nil, &ParseError{Issues: failed}
+	}
+	return result, nil
+}
+
+func (a ArrayType) parse(value any, coerce bool) (any, error) {
+	return a.Parse(value, coerce)
+}
+
+func (a ArrayType) Min(n int) ArrayType {
+	a.min = &n
+	return a
+}
+
+func (a ArrayType) Max(n int) ArrayType {
+	a.max = &n
+	return a
+}
+
+func (AnyType) Parse(value any, coerce bool) (any, error) {
+	return value, nil
+}
+
+// Optional lets field be absent or null; absent keys stay absent.
+func Optional(field any) OptionalType { return OptionalType{field: wrap(field)} }
+
+func Array(item any) ArrayType { return ArrayType{item: wrap(item)} }
+
+func Any() AnyType { return AnyType{} }
+
 type Fields = map[string]AnyField
 
 type SchemaBuilder struct {
-	fields Fields
+	fields      Fields
+	checks      []schemaCheck
+	description string
+	unknown     unknownKeys
+}
+
+type schemaCheck struct {
+	path  string
+	check func(Dict) error
+}
+
+func wrap(field any) AnyField {
+	switch f := field.(type) {
+	case AnyField:
+		return f
+	case Field[string]:
+		return fieldWrapper[string]{f}
+	case Field[bool]:
+		return fieldWrapper[bool]{f}
+	case Field[int]:
+		return fieldWrapper[int]{f}
+	case Field[int64]:
+		return fieldWrapper[int64]{f}
+	case Field[float64]:
+		return fieldWrapper[float64]{f}
+	case Field[time.Time]:
+		return fieldWrapper[time.Time]{f}
+	case Field[time.Duration]:
+		return fieldWrapper[time.Duration]{f}
+	case Field[Dict]:
+		return fieldWrapper[Dict]{f}
+	case Field[UploadFile]:
+		return fieldWrapper[UploadFile]{f}
+	case Field[[]UploadFile]:
+		return fieldWrapper[[]UploadFile]{f}
+	case Field[any]:
+		return fieldWrapper[any]{f}
+	default:
+		panic(fmt.Sprintf("unsupported field type: %T", field))
+	}
 }
 
 func Schema(fields map[string]any) *SchemaBuilder {
 	wrapped := make(Fields)
 	for name, field := range fields {
-		switch f := field.(type) {
-		case Field[string]:
-			wrapped[name] = fieldWrapper[string]{f}
-		case Field[bool]:
-			wrapped[name] = fieldWrapper[bool]{f}
-		case Field[int]:
-			wrapped[name] = fieldWrapper[int]{f}
-		case Field[int64]:
-			wrapped[name] = fieldWrapper[int64]{f}
-		case Field[float64]:
-			wrapped[name] = fieldWrapper[float64]{f}
-		default:
-			panic(fmt.Sprintf("unsupported field type: %T", field))
-		}
+		wrapped[name] = wrap(field)
 	}
 	return &SchemaBuilder{fields: wrapped}
 }
@@ -171,20 +468,83 @@ func (s *SchemaBuilder) Parse(data Dict, args ...bool) (Dict, error) {
 		coerce = args[0]
 	}
 	result := make(Dict)
+	var failed []Issue
 
-	for name, field := range s.fields {
-		value, exists := data[name]
-		if !exists {
-			value = ""
+	for _, name := range slices.Sorted(maps.Keys(s.fields)) {
+		field := s.fields[name]
+		var parsed any
+		var err error
+		if value, exists := data[name]; exists {
+			parsed, err = field.parse(value, coerce)
+		} else {
+			var skip bool
+			if parsed, skip, err = absent(field, coerce); skip {
+				continue
+			}
 		}
-
-		parsed, err := field.parse(value, coerce)
 		if err != nil {
-			return nil, fmt.Errorf("parsing failed for field '%s': %w", name, err)
+			failed = append(failed, issues(name, err)...)
+			continue
 		}
 
 		result[name] = parsed
 	}
 
+	switch s.unknown {
+	case unknownStrict:
+		var extra []string
+		for key := range data {
+			if _, ok := s.fields[key]; !ok {
+				extra = append(extra, key)
+			}
+		}
+		if extra != nil {
+			slices.Sort(extra)
+			failed = append(failed, *issue(CodeUnrecognizedKeys, Dict{"keys": extra},
+				"unrecognized keys %s", quoteAll(extra)))
+		}
+	case unknownPassthrough:
+		for key, value := range data {
+			if _, ok := s.fields[key]; !ok {
+				result[key] = value
+			}
+		}
+	}
+
+	// cross-field checks only see fully parsed data
+	if failed == nil {
+		for _, c := range s.checks {
+			if err := c.check(result); err != nil {
+				failed = append(failed, issues(c.path, err)...)
+			}
+		}
+	}
+	if failed != nil {
+		localize(failed)
+		return nil, &ParseError{Issues: failed}
+	}
+
 	return result, nil
 }
+
+// RequiredKeys returns the sorted keys whose absence Parse, given the same
+// coerce flag, rejects. JSONSchema lists those of Parse without coercion.
+func (s *SchemaBuilder) RequiredKeys(args ...bool) []string {
+	coerce := len(args) > 0 && args[0]
+	var keys []string
+	for _, name := range slices.Sorted(maps.Keys(s.fields)) {
+		if _, skip, err := absent(s.fields[name], coerce); !skip && err != nil {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+// parse lets a schema be nested as a field of another schema.
+func (s *SchemaBuilder) parse(value any, coerce bool) (any, error) {
+	data, ok := asDict(value)
+	if !ok {
+		return nil, invalidType("object", value)
+	}
+	return s.Parse(data, coerce)
+}
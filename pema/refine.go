@@ -0,0 +1,95 @@
+//go:build js && wasm
+
+package pema
+
+import "strings"
+
+type RefineType[T any] struct {
+	field Field[T]
+	check func(T) error
+}
+
+type TransformType[T, U any] struct {
+	field     Field[T]
+	transform func(T) (U, error)
+}
+
+type PreprocessType[T any] struct {
+	field      Field[T]
+	preprocess func(any) any
+}
+
+func (r RefineType[T]) Parse(value any, coerce bool) (T, error) {
+	parsed, err := r.field.Parse(value, coerce)
+	if err != nil {
+		return parsed, err
+	}
+	if err := r.check(parsed); err != nil {
+		var zero T
+		return zero, err
+	}
+	return parsed, nil
+}
+
+func (r RefineType[T]) parse(value any, coerce bool) (any, error) {
+	return r.Parse(value, coerce)
+}
+
+func (t TransformType[T, U]) Parse(value any, coerce bool) (U, error) {
+	parsed, err := t.field.Parse(value, coerce)
+	if err != nil {
+		var zero U
+		return zero, err
+	}
+	return t.transform(parsed)
+}
+
+func (t TransformType[T, U]) parse(value any, coerce bool) (any, error) {
+	return t.Parse(value, coerce)
+}
+
+func (p PreprocessType[T]) Parse(value any, coerce bool) (T, error) {
+	return p.field.Parse(p.preprocess(value), coerce)
+}
+
+func (p PreprocessType[T]) parse(value any, coerce bool) (any, error) {
+	return p.Parse(value, coerce)
+}
+
+func (r RefineType[T]) unwrap() any       { return r.field }
+func (t TransformType[T, U]) unwrap() any { return t.field }
+func (p PreprocessType[T]) unwrap() any   { return p.field }
+
+// Refine adds check to field; a non-nil error fails the field. Return an
+// *Issue to control the issue code and params, any other error is reported
+// with CodeCustom.
+func Refine[T any](field Field[T], check func(T) error) RefineType[T] {
+	return RefineType[T]{field: field, check: check}
+}
+
+// Transform maps the parsed value of field to a new value, possibly of a
+// different type.
+func Transform[T, U any](field Field[T], transform func(T) (U, error)) TransformType[T, U] {
+	return TransformType[T, U]{field: field, transform: transform}
+}
+
+// Preprocess rewrites the raw input before field parses it.
+func Preprocess[T any](field Field[T], preprocess func(any) any) PreprocessType[T] {
+	return PreprocessType[T]{field: field, preprocess: preprocess}
+}
+
+// Refine returns a copy of the schema that additionally runs check on the
+// parsed data, once every field has passed. Issues from check are reported
+// at path, e.g.
+//
+//	schema.Refine(func(d pema.Dict) error {
+//		if d["password"] != d["confirm"] {
+//			return errors.New("passwords do not match")
+//		}
+//		return nil
+//	}, "confirm")
+func (s *SchemaBuilder) Refine(check func(Dict) error, path ...string) *SchemaBuilder {
+	clone := s.clone()
+	clone.checks = append(clone.checks, schemaCheck{path: strings.Join(path, "."), check: check})
+	return clone
+}
@@ -0,0 +1,123 @@
+//go:build js && wasm
+
+package pema
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRefine(t *testing.T) {
+	even := Refine(Int(), func(n int) error {
+		if n%2 != 0 {
+			return errors.New("must be even")
+		}
+		return nil
+	})
+	positive := Refine(even, func(n int) error {
+		if n <= 0 {
+			return issue(CodeTooSmall, Dict{"min": 1}, "must be positive")
+		}
+		return nil
+	})
+	tests := []struct {
+		value any
+		want  []string
+	}{
+		{4, nil},
+		{3, []string{":custom"}},
+		{-2, []string{":too_small"}},
+		{"4", []string{":invalid_type"}},
+	}
+	for _, test := range tests {
+		_, err := positive.Parse(test.value, false)
+		if got := codes(err); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: got %v, want %v", test.value, got, test.want)
+		}
+	}
+}
+
+func TestTransformAndPreprocess(t *testing.T) {
+	upper := Transform(String(), func(s string) (string, error) { return strings.ToUpper(s), nil })
+	trimmed := Preprocess(upper, func(v any) any {
+		if s, ok := v.(string); ok {
+			return strings.TrimSpace(s)
+		}
+		return v
+	})
+	length := Transform(trimmed, func(s string) (int, error) {
+		if s == "" {
+			return 0, errors.New("empty")
+		}
+		return len(s), nil
+	})
+	schema := Schema(map[string]any{"name": trimmed, "length": length})
+	got, err := schema.Parse(Dict{"name": " ab ", "length": " abc "})
+	if want := (Dict{"name": "AB", "length": 3}); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, %v, want %v", got, err, want)
+	}
+	_, err = schema.Parse(Dict{"name": "a", "length": "  "})
+	if got, want := codes(err), []string{"length:custom"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestOptionalThroughWrappers(t *testing.T) {
+	fields := map[string]any{
+		"refined":      Refine(Optional(String()), func(any) error { return nil }),
+		"transformed":  Transform(Optional(Int()), func(v any) (any, error) { return v, nil }),
+		"preprocessed": Preprocess(Optional(Int()), func(v any) any { return v }),
+		"described":    Describe(Optional(Int()), "an int"),
+		"nested":       Describe(Refine(Optional(Int()), func(any) error { return nil }), "deep"),
+	}
+	schema := Schema(fields)
+	got, err := schema.Parse(Dict{})
+	if err != nil || len(got) != 0 {
+		t.Errorf("got %v, %v, want nothing", got, err)
+	}
+	if required := schema.JSONSchema()["required"]; required != nil {
+		t.Errorf("got required %v", required)
+	}
+	for name := range fields {
+		if !optional(schema.fields[name]) {
+			t.Errorf("%s is not optional", name)
+		}
+	}
+}
+
+func TestSchemaRefine(t *testing.T) {
+	schema := Schema(map[string]any{
+		"password": String(),
+		"confirm":  String(),
+		"nested": Schema(map[string]any{"a": Int(), "b": Int()}).Refine(func(d Dict) error {
+			if d["a"].(int) > d["b"].(int) {
+				return errors.New("a after b")
+			}
+			return nil
+		}, "b"),
+	}).Refine(func(d Dict) error {
+		if d["password"] != d["confirm"] {
+			return errors.New("passwords do not match")
+		}
+		return nil
+	}, "confirm")
+
+	tests := []struct {
+		data Dict
+		want []string
+	}{
+		{Dict{"password": "x", "confirm": "x", "nested": Dict{"a": 1, "b": 2}}, nil},
+		{Dict{"password": "x", "confirm": "y", "nested": Dict{"a": 1, "b": 2}}, []string{"confirm:custom"}},
+		{Dict{"password": "x", "confirm": "x", "nested": Dict{"a": 3, "b": 2}}, []string{"nested.b:custom"}},
+		// field issues come first; the checks only see parsed data
+		{Dict{"password": "x", "confirm": "y", "nested": Dict{"a": "3", "b": 2}}, []string{"nested.a:invalid_type"}},
+	}
+	for _, test := range tests {
+		_, err := schema.Parse(test.data)
+		if got := codes(err); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: got %v, want %v", test.data, got, test.want)
+		}
+	}
+}
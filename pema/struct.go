@@ -0,0 +1,403 @@
+//go:build js && wasm
+
+package pema
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType        = reflect.TypeFor[time.Time]()
+	durationType    = reflect.TypeFor[time.Duration]()
+	uploadFileType  = reflect.TypeFor[UploadFile]()
+	uploadFilesType = reflect.TypeFor[[]UploadFile]()
+)
+
+type structField struct {
+	index       []int
+	name        string
+	rules       map[string]string
+	description string
+}
+
+// Struct derives a schema from the fields of T. Keys follow the json tag,
+// falling back to the Go field name, and rules come from the pema tag:
+//
+//	Name  string  `json:"name" pema:"min=1,max=50"`
+//	Email string  `json:"email" pema:"email"`
+//	Kind  string  `json:"kind" pema:"enum=a|b"`
+//	Age   *int    `json:"age" pema:"min=18"`
+//
+// Pointer fields and fields tagged optional may be absent. A description
+// tag is carried over into JSONSchema output.
+func Struct[T any]() *SchemaBuilder {
+	return structSchema(reflect.TypeFor[T](), map[reflect.Type]*RefType{})
+}
+
+// ParseInto validates data against schema and stores the result in a T.
+func ParseInto[T any](schema *SchemaBuilder, data Dict, coerce ...bool) (T, error) {
+	var out T
+	parsed, err := schema.Parse(data, coerce...)
+	if err != nil {
+		return out, err
+	}
+	if err := assign(reflect.ValueOf(&out).Elem(), parsed); err != nil {
+		return out, err
+	}
+	return out, nil
+}
+
+// structSchema builds the schema of t. Types still being built are in
+// building; meeting one again means the type is recursive, and it is
+// referred to lazily.
+func structSchema(t reflect.Type, building map[reflect.Type]*RefType) *SchemaBuilder {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("pema.Struct: expected struct, got %s", t))
+	}
+	ref := &RefType{ref: t.String()}
+	building[t] = ref
+	defer delete(building, t)
+
+	fields := make(Fields)
+	for _, f := range structFields(t) {
+		field := fieldFor(t.FieldByIndex(f.index).Type, f.rules, building)
+		if f.description != "" {
+			field = describeField(field, f.description)
+		}
+		fields[f.name] = field
+	}
+	schema := &SchemaBuilder{fields: fields}
+	ref.field = schema
+	return schema
+}
+
+func structFields(t reflect.Type) []structField {
+	var fields []structField
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous && indirect(f.Type).Kind() == reflect.Struct {
+			continue
+		}
+		tag := f.Tag.Get("pema")
+		if tag == "-" {
+			continue
+		}
+		name := f.Name
+		if j, _, _ := strings.Cut(f.Tag.Get("json"), ","); j == "-" {
+			continue
+		} else if j != "" {
+			name = j
+		}
+		fields = append(fields, structField{
+			index:       f.Index,
+			name:        name,
+			rules:       parseRules(tag),
+			description: f.Tag.Get("description"),
+		})
+	}
+	return fields
+}
+
+func parseRules(tag string) map[string]string {
+	rules := map[string]string{}
+	for rule := range strings.SplitSeq(tag, ",") {
+		if rule = strings.TrimSpace(rule); rule == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(rule, "=")
+		rules[key] = value
+	}
+	return rules
+}
+
+func fieldFor(t reflect.Type, rules map[string]string, building map[reflect.Type]*RefType) AnyField {
+	if _, ok := rules["optional"]; ok {
+		delete(rules, "optional")
+		return Optional(fieldFor(t, rules, building))
+	}
+	switch {
+	case t.Kind() == reflect.Pointer:
+		return Optional(fieldFor(t.Elem(), rules, building))
+	case t == timeType:
+		f := Time()
+		if layout, ok := rules["layout"]; ok {
+			f = Time(layout)
+		}
+		if _, ok := rules["date"]; ok {
+			f = Date()
+		}
+		if _, ok := rules["unix"]; ok {
+			f = Unix()
+		}
+		if _, ok := rules["unixmilli"]; ok {
+			f = UnixMilli()
+		}
+		return wrap(f)
+	case t == durationType:
+		return wrap(Duration())
+	case t == uploadFileType:
+		return wrap(File())
+	case t == uploadFilesType:
+		lo, _ := intRule(rules, "min")
+		hi, _ := intRule(rules, "max")
+		return wrap(File().Multiple(int(lo), int(hi)))
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		if enum, ok := rules["enum"]; ok {
+			return wrap(Enum(strings.Split(enum, "|")...))
+		}
+		f := String()
+		if n, ok := intRule(rules, "min"); ok {
+			f = f.Min(int(n))
+		}
+		if n, ok := intRule(rules, "max"); ok {
+			f = f.Max(int(n))
+		}
+		if _, ok := rules["email"]; ok {
+			f = f.Email()
+		}
+		if pattern, ok := rules["pattern"]; ok {
+			f = f.Pattern(pattern)
+		}
+		return wrap(f)
+	case reflect.Bool:
+		return wrap(Boolean())
+	case reflect.Int:
+		f := Int()
+		if n, ok := intRule(rules, "min"); ok {
+			f = f.Min(int(n))
+		}
+		if n, ok := intRule(rules, "max"); ok {
+			f = f.Max(int(n))
+		}
+		return wrap(f)
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		lo, hi := intRange(t)
+		if n, ok := intRule(rules, "min"); ok {
+			lo = max(lo, n)
+		}
+		if n, ok := intRule(rules, "max"); ok {
+			hi = min(hi, n)
+		}
+		return wrap(Int64().Min(lo).Max(hi))
+	case reflect.Float32, reflect.Float64:
+		f := Float()
+		if n, ok := floatRule(rules, "min"); ok {
+			f = f.Min(n)
+		}
+		if n, ok := floatRule(rules, "max"); ok {
+			f = f.Max(n)
+		}
+		return wrap(f)
+	case reflect.Struct:
+		if ref, ok := building[t]; ok {
+			return ref
+		}
+		return structSchema(t, building)
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			panic(fmt.Sprintf("pema.Struct: unsupported map key type %s", t.Key()))
+		}
+		return recordType{value: fieldFor(t.Elem(), map[string]string{}, building)}
+	case reflect.Slice:
+		f := Array(fieldFor(t.Elem(), map[string]string{}, building))
+		if n, ok := intRule(rules, "min"); ok {
+			f = f.Min(int(n))
+		}
+		if n, ok := intRule(rules, "max"); ok {
+			f = f.Max(int(n))
+		}
+		return f
+	case reflect.Interface:
+		return wrap(Any())
+	default:
+		panic(fmt.Sprintf("pema.Struct: unsupported field type %s", t))
+	}
+}
+
+func intRule(rules map[string]string, key string) (int64, bool) {
+	raw, ok := rules[key]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		panic(fmt.Sprintf("pema.Struct: invalid %s=%s", key, raw))
+	}
+	return n, true
+}
+
+func floatRule(rules map[string]string, key string) (float64, bool) {
+	raw, ok := rules[key]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		panic(fmt.Sprintf("pema.Struct: invalid %s=%s", key, raw))
+	}
+	return n, true
+}
+
+func intRange(t reflect.Type) (int64, int64) {
+	bits := t.Bits()
+	switch t.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if bits == 64 {
+			return 0, math.MaxInt64
+		}
+		return 0, 1<<bits - 1
+	default:
+		return -1 << (bits - 1), 1<<(bits-1) - 1
+	}
+}
+
+func assign(dst reflect.Value, src any) error {
+	if src == nil {
+		return nil
+	}
+	switch {
+	case dst.Kind() == reflect.Pointer:
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return assign(dst.Elem(), src)
+	case dst.Kind() == reflect.Interface && dst.NumMethod() == 0:
+		dst.Set(reflect.ValueOf(src))
+		return nil
+	case dst.Kind() == reflect.Struct && dst.Type() != timeType && dst.Type() != uploadFileType:
+		data, ok := asDict(src)
+		if !ok {
+			return fmt.Errorf("cannot assign %T to %s", src, dst.Type())
+		}
+		for _, f := range structFields(dst.Type()) {
+			value, ok := data[f.name]
+			if !ok || value == nil {
+				continue
+			}
+			field, err := fieldByIndex(dst, f.index)
+			if err == nil {
+				err = assign(field, value)
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", f.name, err)
+			}
+		}
+		return nil
+	case dst.Kind() == reflect.Map:
+		data, ok := asDict(src)
+		if !ok {
+			break
+		}
+		m := reflect.MakeMapWithSize(dst.Type(), len(data))
+		for key, item := range data {
+			value := reflect.New(dst.Type().Elem()).Elem()
+			if err := assign(value, item); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			m.SetMapIndex(reflect.ValueOf(key).Convert(dst.Type().Key()), value)
+		}
+		dst.Set(m)
+		return nil
+	case dst.Kind() == reflect.Slice:
+		items, ok := src.([]any)
+		if !ok {
+			break
+		}
+		slice := reflect.MakeSlice(dst.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := assign(slice.Index(i), item); err != nil {
+				return fmt.Errorf("%d: %w", i, err)
+			}
+		}
+		dst.Set(slice)
+		return nil
+	}
+
+	v := reflect.ValueOf(src)
+	switch {
+	case v.Type().AssignableTo(dst.Type()):
+		dst.Set(v)
+	case v.CanConvert(dst.Type()) && (v.Kind() == reflect.String) == (dst.Kind() == reflect.String):
+		dst.Set(v.Convert(dst.Type()))
+	default:
+		return fmt.Errorf("cannot assign %T to %s", src, dst.Type())
+	}
+	return nil
+}
+
+// fieldByIndex is FieldByIndex allocating nil embedded pointers on the way.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, fmt.Errorf("cannot set embedded pointer to unexported %s", v.Type().Elem())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		return t.Elem()
+	}
+	return t
+}
+
+// recordType parses an object of arbitrary keys, each value with the
+// same field, for map fields of structs.
+type recordType struct {
+	value AnyField
+}
+
+func (r recordType) parse(value any, coerce bool) (any, error) {
+	data, ok := asDict(value)
+	if !ok {
+		return nil, invalidType("object", value)
+	}
+	result := make(Dict, len(data))
+	var failed []Issue
+	for _, key := range slices.Sorted(maps.Keys(data)) {
+		parsed, err := r.value.parse(data[key], coerce)
+		if err != nil {
+			failed = append(failed, issues(key, err)...)
+			continue
+		}
+		result[key] = parsed
+	}
+	if failed != nil {
+		return nil, &ParseError{Issues: failed}
+	}
+	return result, nil
+}
+
+func (r recordType) jsonSchema() Dict {
+	return Dict{"type": "object", "additionalProperties": jsonSchemaOf(r.value)}
+}
+
+// describeField keeps Optional outermost so the schema still treats the
+// field as optional.
+func describeField(field AnyField, description string) AnyField {
+	if o, ok := field.(OptionalType); ok {
+		return Optional(Describe(o.field, description))
+	}
+	return Describe(field, description)
+}
@@ -0,0 +1,189 @@
+//go:build js && wasm
+
+package pema
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// codes lists the path and code of every issue in err.
+func codes(err error) []string {
+	if err == nil {
+		return nil
+	}
+	var out []string
+	for _, it := range issues("", err) {
+		out = append(out, it.Path+":"+it.Code)
+	}
+	return out
+}
+
+type Base struct {
+	ID int `json:"id"`
+}
+
+type user struct {
+	*Base
+	Name    string            `json:"name" pema:"min=1,max=5"`
+	Email   string            `json:"email,omitempty" pema:"email,optional"`
+	Age     *int              `json:"age" pema:"min=18"`
+	Small   uint8             `json:"small"`
+	Tags    []string          `json:"tags" pema:"max=2"`
+	Scores  map[string]int    `json:"scores"`
+	Extra   any               `json:"extra"`
+	When    time.Time         `json:"when" pema:"date"`
+	Skipped string            `json:"-"`
+	Nested  struct{ On bool } `json:"nested"`
+}
+
+type node struct {
+	Value    int    `json:"value"`
+	Children []node `json:"children"`
+	Next     *node  `json:"next"`
+}
+
+func userData(overrides Dict) Dict {
+	data := Dict{
+		"id":     1,
+		"name":   "ann",
+		"age":    20,
+		"small":  7,
+		"tags":   []any{"a"},
+		"scores": Dict{"x": 1, "y": 2},
+		"extra":  "anything",
+		"when":   "2024-02-03",
+		"nested": Dict{"On": true},
+	}
+	maps.Copy(data, overrides)
+	return data
+}
+
+func TestParseInto(t *testing.T) {
+	age := 20
+	got, err := ParseInto[user](Struct[user](), userData(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := user{
+		Base:   &Base{ID: 1},
+		Name:   "ann",
+		Age:    &age,
+		Small:  7,
+		Tags:   []string{"a"},
+		Scores: map[string]int{"x": 1, "y": 2},
+		Extra:  "anything",
+		When:   time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC),
+	}
+	want.Nested.On = true
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseIntoIssues(t *testing.T) {
+	tests := []struct {
+		data Dict
+		want []string
+	}{
+		{Dict{"name": ""}, []string{"name:too_small"}},
+		{Dict{"name": "abcdef", "small": 256}, []string{"name:too_big", "small:too_big"}},
+		{Dict{"age": 17, "tags": []any{"a", "b", "c"}}, []string{"age:too_small", "tags:too_big"}},
+		{Dict{"email": "nope"}, []string{"email:invalid_string"}},
+		{Dict{"scores": Dict{"x": "1", "y": 2.5}}, []string{"scores.x:invalid_type", "scores.y:not_integer"}},
+		{Dict{"scores": []any{}}, []string{"scores:invalid_type"}},
+		{Dict{"nested": Dict{"On": 1}}, []string{"nested.On:invalid_type"}},
+	}
+	schema := Struct[user]()
+	for _, test := range tests {
+		_, err := ParseInto[user](schema, userData(test.data))
+		if got := codes(err); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: got %v, want %v", test.data, got, test.want)
+		}
+	}
+}
+
+func TestParseIntoEmbeddedPointer(t *testing.T) {
+	if _, ok := Struct[user]().fields["Base"]; ok {
+		t.Error("embedded pointer is a field of its own")
+	}
+
+	type note struct {
+		Text *string `json:"text"`
+	}
+	type withNote struct {
+		*note
+		Name string `json:"name"`
+	}
+	schema := Struct[withNote]()
+	got, err := ParseInto[withNote](schema, Dict{"name": "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.note != nil {
+		t.Errorf("got %+v, want a nil embedded pointer", got.note)
+	}
+	// the embedded type is unexported, so it cannot be allocated
+	if _, err := ParseInto[withNote](schema, Dict{"name": "a", "text": "b"}); err == nil {
+		t.Error("unexported embedded pointer set")
+	}
+}
+
+func TestParseIntoRecursive(t *testing.T) {
+	schema := Struct[node]()
+	data := Dict{
+		"value":    1,
+		"children": []any{Dict{"value": 2, "children": []any{}}},
+		"next":     Dict{"value": 3, "children": []any{}},
+	}
+	got, err := ParseInto[node](schema, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := node{Value: 1, Children: []node{{Value: 2, Children: []node{}}}, Next: &node{Value: 3, Children: []node{}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	data["children"] = []any{Dict{"value": "x", "children": []any{}}}
+	_, err = ParseInto[node](schema, data)
+	if got, want := codes(err), []string{"children.0.value:invalid_type"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseIntoInterface(t *testing.T) {
+	type named struct {
+		Value fmt.Stringer `json:"value"`
+	}
+	schema := Schema(map[string]any{"value": Any()})
+	_, err := ParseInto[named](schema, Dict{"value": "text"})
+	if err == nil {
+		t.Error("string assigned to fmt.Stringer")
+	}
+	got, err := ParseInto[named](schema, Dict{"value": time.Second})
+	if err != nil || got.Value != time.Second {
+		t.Errorf("got %v, %v", got.Value, err)
+	}
+}
+
+func TestStructUnsupported(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("map with int keys accepted")
+		}
+	}()
+	Struct[struct{ M map[int]string }]()
+}
+
+func TestParseIntoParseError(t *testing.T) {
+	_, err := ParseInto[user](Struct[user](), userData(Dict{"name": 1}))
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("got %T, want *ParseError", err)
+	}
+}
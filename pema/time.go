@@ -0,0 +1,224 @@
+//go:build js && wasm
+
+package pema
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type TimeType struct {
+	layouts []string
+	unit    time.Duration
+	before  *time.Time
+	after   *time.Time
+}
+
+type DurationType struct {
+	min *time.Duration
+	max *time.Duration
+}
+
+func (t TimeType) Parse(value any, coerce bool) (time.Time, error) {
+	parsed, err := t.parse(value, coerce)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if t.before != nil && !parsed.Before(*t.before) {
+		return time.Time{}, issue(CodeTooBig, Dict{"max": t.before.Format(time.RFC3339), "type": "date"},
+			"expected time before %s, got %s", t.before.Format(time.RFC3339), parsed.Format(time.RFC3339))
+	}
+	if t.after != nil && !parsed.After(*t.after) {
+		return time.Time{}, issue(CodeTooSmall, Dict{"min": t.after.Format(time.RFC3339), "type": "date"},
+			"expected time after %s, got %s", t.after.Format(time.RFC3339), parsed.Format(time.RFC3339))
+	}
+	return parsed, nil
+}
+
+func (t TimeType) parse(value any, coerce bool) (time.Time, error) {
+	if v, ok := value.(time.Time); ok {
+		return v, nil
+	}
+	if t.unit != 0 {
+		f, ok := number(value)
+		if !ok && coerce {
+			if s, isString := value.(string); isString {
+				parsed, err := strconv.ParseFloat(s, 64)
+				if err != nil {
+					return time.Time{}, issue(CodeInvalidDate, Dict{"received": s},
+						"cannot parse '%s' as timestamp", s)
+				}
+				f, ok = parsed, true
+			}
+		}
+		if !ok {
+			return time.Time{}, invalidType("unix timestamp", value)
+		}
+		// NaN fails both comparisons; the range is that of an int64
+		// count of seconds or milliseconds
+		if !(f >= math.MinInt64 && f < math.MaxInt64) {
+			return time.Time{}, issue(CodeInvalidDate, Dict{"received": f},
+				"expected unix timestamp, got %v", f)
+		}
+		return fromEpoch(f, t.unit), nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return time.Time{}, invalidType("time string", value)
+	}
+	for _, layout := range t.layouts {
+		if parsed, err := time.Parse(layout, s); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, issue(CodeInvalidDate, Dict{"received": s, "layouts": t.layouts},
+		"cannot parse '%s' as time (expected %s)", s, strings.Join(t.layouts, " or "))
+}
+
+func (t TimeType) Before(limit time.Time) TimeType {
+	t.before = &limit
+	return t
+}
+
+func (t TimeType) After(limit time.Time) TimeType {
+	t.after = &limit
+	return t
+}
+
+func (d DurationType) Parse(value any, coerce bool) (time.Duration, error) {
+	var parsed time.Duration
+	switch v := value.(type) {
+	case time.Duration:
+		parsed = v
+	case string:
+		var err error
+		if parsed, err = parseDuration(v); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, invalidType("duration string", value)
+	}
+	if d.min != nil && parsed < *d.min {
+		return 0, issue(CodeTooSmall, Dict{"min": d.min.String(), "type": "duration"},
+			"expected duration of at least %s, got %s", d.min, parsed)
+	}
+	if d.max != nil && parsed > *d.max {
+		return 0, issue(CodeTooBig, Dict{"max": d.max.String(), "type": "duration"},
+			"expected duration of at most %s, got %s", d.max, parsed)
+	}
+	return parsed, nil
+}
+
+func (d DurationType) Min(limit time.Duration) DurationType {
+	d.min = &limit
+	return d
+}
+
+func (d DurationType) Max(limit time.Duration) DurationType {
+	d.max = &limit
+	return d
+}
+
+// Time parses strings in the given layouts, RFC 3339 if none are given.
+func Time(layouts ...string) TimeType {
+	if len(layouts) == 0 {
+		layouts = []string{time.RFC3339Nano}
+	}
+	return TimeType{layouts: layouts}
+}
+
+func Date() TimeType { return TimeType{layouts: []string{time.DateOnly}} }
+
+// Unix parses seconds since the Unix epoch, fractions included.
+func Unix() TimeType { return TimeType{unit: time.Second} }
+
+// UnixMilli parses milliseconds since the Unix epoch.
+func UnixMilli() TimeType { return TimeType{unit: time.Millisecond} }
+
+// Duration parses ISO 8601 durations (PT1H30M) and Go durations (1h30m).
+func Duration() DurationType { return DurationType{} }
+
+func fromEpoch(f float64, unit time.Duration) time.Time {
+	whole, frac := math.Modf(f)
+	if unit == time.Millisecond {
+		return time.UnixMilli(int64(whole)).Add(time.Duration(frac * 1e6)).UTC()
+	}
+	return time.Unix(int64(whole), int64(frac*1e9)).UTC()
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	if d, err := parseISODuration(s); err == nil {
+		return d, nil
+	} else if strings.Contains(s, "P") {
+		return 0, issue(CodeInvalidDuration, Dict{"received": s}, "cannot parse '%s' as duration: %s", s, err)
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, issue(CodeInvalidDuration, Dict{"received": s}, "cannot parse '%s' as duration", s)
+	}
+	return d, nil
+}
+
+func parseISODuration(s string) (time.Duration, error) {
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, s = -1, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	rest, ok := strings.CutPrefix(s, "P")
+	if !ok || rest == "" || strings.HasSuffix(rest, "T") {
+		return 0, errors.New("not an ISO 8601 duration")
+	}
+
+	var total float64
+	inTime := false
+	for rest != "" {
+		if rest[0] == 'T' {
+			if inTime {
+				return 0, errors.New("repeated time designator")
+			}
+			inTime, rest = true, rest[1:]
+			continue
+		}
+		i := 0
+		for i < len(rest) && (rest[i] >= '0' && rest[i] <= '9' || rest[i] == '.' || rest[i] == ',') {
+			i++
+		}
+		if i == 0 || i == len(rest) {
+			return 0, errors.New("expected number followed by designator")
+		}
+		n, err := strconv.ParseFloat(strings.Replace(rest[:i], ",", ".", 1), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number '%s'", rest[:i])
+		}
+		var unit time.Duration
+		switch designator := rest[i]; {
+		case !inTime && designator == 'W':
+			unit = 7 * 24 * time.Hour
+		case !inTime && designator == 'D':
+			unit = 24 * time.Hour
+		case !inTime && (designator == 'Y' || designator == 'M'):
+			return 0, errors.New("years and months have no fixed length")
+		case inTime && designator == 'H':
+			unit = time.Hour
+		case inTime && designator == 'M':
+			unit = time.Minute
+		case inTime && designator == 'S':
+			unit = time.Second
+		default:
+			return 0, fmt.Errorf("unexpected designator '%c'", designator)
+		}
+		total += n * float64(unit)
+		rest = rest[i+1:]
+	}
+	if total > math.MaxInt64 {
+		return 0, errors.New("duration out of range")
+	}
+	return sign * time.Duration(total), nil
+}
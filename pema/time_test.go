@@ -0,0 +1,42 @@
+//go:build js && wasm
+
+package pema
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestUnixRange(t *testing.T) {
+	tests := []struct {
+		field TimeType
+		value any
+		ok    bool
+	}{
+		{Unix(), 0, true},
+		{Unix(), 1.5, true},
+		{Unix(), -1e18, true},
+		{Unix(), 1e300, false},
+		{Unix(), -1e300, false},
+		{Unix(), math.Inf(1), false},
+		{Unix(), math.NaN(), false},
+		{Unix(), float64(math.MaxInt64), false},
+		{UnixMilli(), 1e18, true},
+		{UnixMilli(), 1e19, false},
+	}
+	for _, test := range tests {
+		_, err := test.field.Parse(test.value, false)
+		if (err == nil) != test.ok {
+			t.Errorf("%v: got %v, want ok %t", test.value, err, test.ok)
+		}
+		if err != nil && !test.ok && err.(*Issue).Code != CodeInvalidDate {
+			t.Errorf("%v: got code %s, want %s", test.value, err.(*Issue).Code, CodeInvalidDate)
+		}
+	}
+
+	got, err := UnixMilli().Parse(1500.5, false)
+	if want := time.UnixMilli(1500).Add(500 * time.Microsecond).UTC(); err != nil || !got.Equal(want) {
+		t.Errorf("got %v, %v, want %v", got, err, want)
+	}
+}
@@ -0,0 +1,102 @@
+//go:build js && wasm
+
+package pema
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+type UnionType struct {
+	fields []AnyField
+}
+
+type TaggedType struct {
+	key      string
+	variants map[string]*SchemaBuilder
+}
+
+// Parse returns the first member that accepts value. Members are tried
+// strictly first, so that coercion never shadows an exact match.
+func (u UnionType) Parse(value any, coerce bool) (any, error) {
+	passes := []bool{false}
+	if coerce {
+		passes = append(passes, true)
+	}
+	var errs []string
+	for _, c := range passes {
+		errs = errs[:0]
+		for i, field := range u.fields {
+			parsed, err := field.parse(value, c)
+			if err == nil {
+				return parsed, nil
+			}
+			errs = append(errs, fmt.Sprintf("[%d] %s", i, err))
+		}
+	}
+	return nil, issue(CodeInvalidUnion, nil, "no union member matched: %s", strings.Join(errs, "; "))
+}
+
+func (t TaggedType) Parse(value any, coerce bool) (Dict, error) {
+	data, ok := asDict(value)
+	if !ok {
+		return nil, invalidType("object", value)
+	}
+	options := slices.Sorted(maps.Keys(t.variants))
+	allowed := quoteAll(options)
+	raw, exists := data[t.key]
+	if !exists {
+		return nil, discriminator(t.key, options, "missing discriminator, expected one of %s", allowed)
+	}
+	tag, ok := raw.(string)
+	if !ok {
+		return nil, discriminator(t.key, options, "expected one of %s, got %s", allowed, typeName(raw))
+	}
+	schema, ok := t.variants[tag]
+	if !ok {
+		return nil, discriminator(t.key, options, "expected one of %s, got %q", allowed, tag)
+	}
+	// the discriminator is known to the union, so a Strict variant must
+	// not see it as unrecognized unless it declares it itself
+	if _, declared := schema.fields[t.key]; !declared {
+		data = maps.Clone(data)
+		delete(data, t.key)
+	}
+	parsed, err := schema.Parse(data, coerce)
+	if err != nil {
+		return nil, err
+	}
+	parsed[t.key] = tag
+	return parsed, nil
+}
+
+func Union(fields ...any) UnionType {
+	wrapped := make([]AnyField, len(fields))
+	for i, field := range fields {
+		wrapped[i] = wrap(field)
+	}
+	return UnionType{fields: wrapped}
+}
+
+func Tagged(key string, variants map[string]*SchemaBuilder) TaggedType {
+	return TaggedType{key: key, variants: maps.Clone(variants)}
+}
+
+func asDict(value any) (Dict, bool) {
+	switch v := value.(type) {
+	case Dict:
+		return v, true
+	case map[string]any:
+		return Dict(v), true
+	default:
+		return nil, false
+	}
+}
+
+func discriminator(key string, options []string, format string, args ...any) *Issue {
+	it := issue(CodeInvalidDiscriminator, Dict{"options": options}, format, args...)
+	it.Path = key
+	return it
+}
@@ -0,0 +1,51 @@
+//go:build js && wasm
+
+package response
+
+import (
+	"syscall/js"
+
+	"github.com/primate-run/go/codec"
+)
+
+// Binary sends data as the response body with the given content type.
+func Binary(data []byte, contentType string, ints ...int) any {
+	var status = tryInt(ints, 0, 200)
+
+	return js.FuncOf(func(this js.Value, args []js.Value) any {
+		if encoding := negotiated(args, contentType, len(data)); encoding != "" {
+			return compressed(data, contentType, encoding, status)
+		}
+		return map[string]any{
+			"handler":     "binary",
+			"body":        uint8Array(data),
+			"contentType": contentType,
+			"status":      status,
+		}
+	})
+}
+
+func uint8Array(data []byte) js.Value {
+	u8 := js.Global().Get("Uint8Array").New(len(data))
+	js.CopyBytesToJS(u8, data)
+	return u8
+}
+
+// CBOR sends body encoded as CBOR.
+func CBOR(body any, ints ...int) any {
+	return encoded(codec.MarshalCBOR, body, "application/cbor", tryInt(ints, 0, 200))
+}
+
+// MsgPack sends body encoded as MessagePack.
+func MsgPack(body any, ints ...int) any {
+	return encoded(codec.MarshalMsgPack, body, "application/msgpack", tryInt(ints, 0, 200))
+}
+
+func encoded(marshal func(any) ([]byte, error), body any, contentType string, status int) any {
+	data, err := marshal(body)
+	if err != nil {
+		// null, as JSON does
+		data, _ = marshal(nil)
+	}
+	return Binary(data, contentType, status)
+}
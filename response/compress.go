@@ -0,0 +1,195 @@
+//go:build js && wasm
+
+package response
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"iter"
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall/js"
+
+	"github.com/primate-run/go/core"
+)
+
+// Compression configures Compress. Zero fields take the defaults.
+type Compression struct {
+	// MinSize is the smallest body worth compressing, in bytes; 1024 by
+	// default, negative to compress bodies of any size.
+	MinSize int
+	// Types lists the compressible media types. An entry may be a range
+	// such as text/* or, starting with +, a structured syntax suffix.
+	Types []string
+}
+
+var defaultCompression = Compression{
+	MinSize: 1024,
+	Types: []string{
+		"text/*", "application/json", "application/xml", "application/javascript",
+		"application/x-ndjson", "application/json-seq", "image/svg+xml", "+json", "+xml",
+	},
+}
+
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// Compress compresses the response result describes with the best
+// encoding the request's Accept-Encoding allows, gzip or deflate, and
+// sets Vary: Accept-Encoding. JSON, XML, Text, Binary and Stream
+// responses are compressed when their type is allowed and, except for
+// streams, their size reaches the minimum; others are sent as they are.
+// Routes opt in through route.With{Compression: ...}.
+func Compress(request core.Request, result any, options ...Compression) any {
+	c := defaultCompression
+	if len(options) > 0 {
+		if options[0].MinSize != 0 {
+			c.MinSize = options[0].MinSize
+		}
+		if options[0].Types != nil {
+			c.Types = options[0].Types
+		}
+	}
+	encoding := acceptEncoding(request.Header("Accept-Encoding"))
+	fn, ok := result.(js.Func)
+	if !ok {
+		fn = JSON(result).(js.Func)
+	}
+
+	inner := js.FuncOf(func(this js.Value, args []js.Value) any {
+		if encoding == "" {
+			return fn.Invoke()
+		}
+		choose := js.FuncOf(func(this js.Value, args []js.Value) any {
+			if c.allows(args[0].String(), args[1].Int()) {
+				return encoding
+			}
+			return ""
+		})
+		defer choose.Release()
+		return fn.Invoke(choose)
+	})
+	return withHeaders(inner, map[string]string{"Vary": "Accept-Encoding"})
+}
+
+func (c Compression) allows(contentType string, size int) bool {
+	if size >= 0 && size < c.MinSize {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	kind, _, _ := strings.Cut(mediaType, "/")
+	return slices.ContainsFunc(c.Types, func(t string) bool {
+		t = strings.ToLower(t)
+		switch {
+		case strings.HasPrefix(t, "+"):
+			return strings.HasSuffix(mediaType, t)
+		case strings.HasSuffix(t, "/*"):
+			return t == kind+"/*"
+		default:
+			return t == mediaType
+		}
+	})
+}
+
+// acceptEncoding picks gzip or deflate, whichever Accept-Encoding rates
+// higher, preferring gzip on a tie. It returns "" if neither is
+// acceptable or the header is absent.
+func acceptEncoding(header string) string {
+	quality := map[string]float64{}
+	wildcard := -1.0
+	for part := range strings.SplitSeq(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		switch name {
+		case "*":
+			wildcard = q
+		case "x-gzip":
+			quality["gzip"] = q
+		default:
+			quality[name] = q
+		}
+	}
+	best, bestQ := "", 0.0
+	for _, encoding := range []string{"gzip", "deflate"} {
+		q, ok := quality[encoding]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// negotiated asks the chooser Compress hands to a response, if any, which
+// encoding to apply to a body of the given type and size, -1 if unknown.
+func negotiated(args []js.Value, contentType string, size int) string {
+	if len(args) == 0 || args[0].Type() != js.TypeFunction {
+		return ""
+	}
+	return args[0].Invoke(contentType, size).String()
+}
+
+func compressed(data []byte, contentType, encoding string, status int) map[string]any {
+	var buf bytes.Buffer
+	w := newCompressor(&buf, encoding)
+	w.Write(data)
+	w.Close()
+
+	return map[string]any{
+		"handler":     "binary",
+		"body":        uint8Array(buf.Bytes()),
+		"contentType": contentType,
+		"status":      status,
+		"headers":     map[string]any{"Content-Encoding": encoding},
+	}
+}
+
+// compressChunks compresses a stream chunk by chunk, flushing after each
+// so that records reach the client as they are produced.
+func compressChunks(seq iter.Seq[[]byte], encoding string) iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+		var buf bytes.Buffer
+		w := newCompressor(&buf, encoding)
+		take := func() []byte {
+			out := bytes.Clone(buf.Bytes())
+			buf.Reset()
+			return out
+		}
+		for chunk := range seq {
+			w.Write(chunk)
+			w.Flush()
+			if !yield(take()) {
+				return
+			}
+		}
+		w.Close()
+		yield(take())
+	}
+}
+
+// newCompressor writes gzip, or deflate in its zlib wrapping as HTTP
+// specifies.
+func newCompressor(w io.Writer, encoding string) compressor {
+	if encoding == "deflate" {
+		return zlib.NewWriter(w)
+	}
+	return gzip.NewWriter(w)
+}
@@ -0,0 +1,54 @@
+//go:build js && wasm
+
+package response
+
+import (
+	"slices"
+	"strings"
+	"syscall/js"
+)
+
+// withHeaders adds headers to the response result describes. Values for
+// Vary are merged with any already set; others replace them. Results that
+// are not responses are sent as JSON.
+func withHeaders(result any, headers map[string]string) any {
+	fn, ok := result.(js.Func)
+	if !ok {
+		fn = JSON(result).(js.Func)
+	}
+
+	return js.FuncOf(func(this js.Value, args []js.Value) any {
+		// pass on what Compress hands down
+		forwarded := make([]any, len(args))
+		for i, arg := range args {
+			forwarded[i] = arg
+		}
+		res := fn.Invoke(forwarded...)
+		set := res.Get("headers")
+		if set.IsUndefined() || set.IsNull() {
+			set = js.Global().Get("Object").New()
+			res.Set("headers", set)
+		}
+		for name, value := range headers {
+			if current := set.Get(name); name == "Vary" && current.Type() == js.TypeString {
+				value = vary(current.String(), value)
+			}
+			set.Set(name, value)
+		}
+		return res
+	})
+}
+
+func vary(current, add string) string {
+	fields := strings.Split(current, ",")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	for field := range strings.SplitSeq(add, ",") {
+		field = strings.TrimSpace(field)
+		if !slices.ContainsFunc(fields, func(f string) bool { return strings.EqualFold(f, field) }) {
+			fields = append(fields, field)
+		}
+	}
+	return strings.Join(slices.DeleteFunc(fields, func(f string) bool { return f == "" }), ", ")
+}
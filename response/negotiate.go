@@ -0,0 +1,103 @@
+//go:build js && wasm
+
+package response
+
+import (
+	"maps"
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/primate-run/go/core"
+)
+
+type mediaRange struct {
+	mediaType string
+	q         float64
+}
+
+// Negotiate answers with the representation that best matches the
+// request's Accept header, offers being keyed by media type:
+//
+//	return response.Negotiate(request, map[string]func() any{
+//		"application/json": func() any { return response.JSON(user) },
+//		"text/html":        func() any { return response.View("User.svelte", props) },
+//	})
+//
+// Among offers the client rates equally, the one matched by the more
+// specific media range wins, then the one the client listed first, then
+// the first in sorted order. A missing Accept header accepts anything.
+// The response carries Vary: Accept; when nothing is acceptable it is a
+// 406.
+func Negotiate(request core.Request, offers map[string]func() any) any {
+	headers := map[string]string{"Vary": "Accept"}
+	accept := request.Header("Accept")
+	if strings.TrimSpace(accept) == "" {
+		accept = "*/*"
+	}
+	ranges := parseAccept(accept)
+
+	best, bestQ, bestSpecificity, bestIndex := "", 0.0, 0, 0
+	for _, offer := range slices.Sorted(maps.Keys(offers)) {
+		q, specificity, index := match(ranges, offer)
+		if q <= 0 {
+			continue
+		}
+		if best == "" || q > bestQ ||
+			q == bestQ && (specificity > bestSpecificity || specificity == bestSpecificity && index < bestIndex) {
+			best, bestQ, bestSpecificity, bestIndex = offer, q, specificity, index
+		}
+	}
+	if best == "" {
+		return withHeaders(JSON(Dict{
+			"error":     "not acceptable",
+			"available": slices.Sorted(maps.Keys(offers)),
+		}, 406), headers)
+	}
+	return withHeaders(offers[best](), headers)
+}
+
+// parseAccept parses an Accept header, skipping malformed ranges.
+func parseAccept(header string) []mediaRange {
+	var ranges []mediaRange
+	for part := range strings.SplitSeq(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if s, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(s, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType, q})
+	}
+	return ranges
+}
+
+// match finds the most specific range matching offer, returning its
+// quality, specificity (3 exact, 2 type/*, 1 */*) and position.
+func match(ranges []mediaRange, offer string) (q float64, specificity, index int) {
+	mediaType, _, err := mime.ParseMediaType(offer)
+	if err != nil {
+		return 0, 0, 0
+	}
+	kind, _, _ := strings.Cut(mediaType, "/")
+	for i, r := range ranges {
+		s := 0
+		switch {
+		case r.mediaType == mediaType:
+			s = 3
+		case r.mediaType == kind+"/*":
+			s = 2
+		case r.mediaType == "*/*":
+			s = 1
+		}
+		if s > specificity {
+			q, specificity, index = r.q, s, i
+		}
+	}
+	return q, specificity, index
+}
@@ -4,6 +4,7 @@ package response
 
 import (
 	"encoding/json"
+	"encoding/xml"
 	"syscall/js"
 
 	"github.com/primate-run/go/types"
@@ -72,3 +73,60 @@ func Error(options ...Dict) any {
 		}
 	})
 }
+
+func JSON(body any, ints ...int) any {
+	var status = tryInt(ints, 0, 200)
+	serde_body, err := json.Marshal(body)
+	if err != nil {
+		serde_body = []byte("null")
+	}
+
+	return js.FuncOf(func(this js.Value, args []js.Value) any {
+		if encoding := negotiated(args, "application/json", len(serde_body)); encoding != "" {
+			return compressed(serde_body, "application/json", encoding, status)
+		}
+		return map[string]any{
+			"handler": "json",
+			"body":    string(serde_body),
+			"status":  status,
+		}
+	})
+}
+
+// XML sends body encoded by encoding/xml, which needs a struct (or other
+// type it can marshal); maps such as Dict cannot be. A body that fails to
+// marshal is answered with a 500 carrying the error.
+func XML(body any, ints ...int) any {
+	var status = tryInt(ints, 0, 200)
+	marshaled, err := xml.Marshal(body)
+	if err != nil {
+		return JSON(Dict{"error": err.Error()}, 500)
+	}
+	var serde_body = xml.Header + string(marshaled)
+
+	return js.FuncOf(func(this js.Value, args []js.Value) any {
+		if encoding := negotiated(args, "application/xml", len(serde_body)); encoding != "" {
+			return compressed([]byte(serde_body), "application/xml", encoding, status)
+		}
+		return map[string]any{
+			"handler": "xml",
+			"body":    serde_body,
+			"status":  status,
+		}
+	})
+}
+
+func Text(body string, ints ...int) any {
+	var status = tryInt(ints, 0, 200)
+
+	return js.FuncOf(func(this js.Value, args []js.Value) any {
+		if encoding := negotiated(args, "text/plain", len(body)); encoding != "" {
+			return compressed([]byte(body), "text/plain; charset=utf-8", encoding, status)
+		}
+		return map[string]any{
+			"handler": "text",
+			"body":    body,
+			"status":  status,
+		}
+	})
+}
@@ -0,0 +1,97 @@
+//go:build js && wasm
+
+package response
+
+import (
+	"encoding/json"
+	"iter"
+	"syscall/js"
+)
+
+// NDJSON streams the values of seq as newline-delimited JSON. Values are
+// encoded as the client pulls them; one that fails to encode ends the
+// stream.
+func NDJSON[T any](seq iter.Seq[T], ints ...int) any {
+	return records(seq, "application/x-ndjson", "", "\n", tryInt(ints, 0, 200))
+}
+
+// JSONSeq streams the values of seq as an RFC 7464 JSON text sequence.
+func JSONSeq[T any](seq iter.Seq[T], ints ...int) any {
+	return records(seq, "application/json-seq", "\x1e", "\n", tryInt(ints, 0, 200))
+}
+
+func records[T any](seq iter.Seq[T], contentType, prefix, suffix string, status int) any {
+	return Stream(func(yield func([]byte) bool) {
+		for value := range seq {
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return
+			}
+			record := make([]byte, 0, len(prefix)+len(encoded)+len(suffix))
+			record = append(append(append(record, prefix...), encoded...), suffix...)
+			if !yield(record) {
+				return
+			}
+		}
+	}, contentType, status)
+}
+
+// Stream sends the chunks of seq as the response body, pulled on demand
+// through a JS ReadableStream.
+func Stream(seq iter.Seq[[]byte], contentType string, ints ...int) any {
+	var status = tryInt(ints, 0, 200)
+
+	return js.FuncOf(func(this js.Value, args []js.Value) any {
+		// streams are of unknown size, so only the type is checked
+		if encoding := negotiated(args, contentType, -1); encoding != "" {
+			return map[string]any{
+				"handler":     "stream",
+				"body":        readableStream(compressChunks(seq, encoding)),
+				"contentType": contentType,
+				"status":      status,
+				"headers":     map[string]any{"Content-Encoding": encoding},
+			}
+		}
+		return map[string]any{
+			"handler":     "stream",
+			"body":        readableStream(seq),
+			"contentType": contentType,
+			"status":      status,
+		}
+	})
+}
+
+func readableStream(seq iter.Seq[[]byte]) js.Value {
+	next, stop := iter.Pull(seq)
+	var pull, cancel js.Func
+	release := func() {
+		stop()
+		pull.Release()
+		cancel.Release()
+	}
+	pull = js.FuncOf(func(this js.Value, args []js.Value) any {
+		controller := args[0]
+		for {
+			chunk, ok := next()
+			if !ok {
+				controller.Call("close")
+				release()
+				return nil
+			}
+			if len(chunk) == 0 {
+				continue
+			}
+			controller.Call("enqueue", uint8Array(chunk))
+			return nil
+		}
+	})
+	cancel = js.FuncOf(func(this js.Value, args []js.Value) any {
+		release()
+		return nil
+	})
+
+	source := js.Global().Get("Object").New()
+	source.Set("pull", pull)
+	source.Set("cancel", cancel)
+	return js.Global().Get("ReadableStream").New(source)
+}
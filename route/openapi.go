@@ -0,0 +1,334 @@
+//go:build js && wasm
+
+package route
+
+import (
+	"encoding/json"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/primate-run/go/core"
+	"github.com/primate-run/go/pema"
+)
+
+var parameter = regexp.MustCompile(`^\[{1,2}(?:\.\.\.)?([^\]]+)\]{1,2}$`)
+
+// OpenAPI returns an OpenAPI 3.1 document describing every committed
+// route. info is merged into the document's info object, which defaults
+// to {"title": "API", "version": "1.0.0"}.
+func OpenAPI(info ...core.Dict) core.Dict {
+	meta := core.Dict{"title": "API", "version": "1.0.0"}
+	if len(info) > 0 {
+		maps.Copy(meta, info[0])
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	paths := core.Dict{}
+	schemas := components()
+	for _, scope := range slices.Sorted(maps.Keys(scopes)) {
+		item, _ := paths[openAPIPath(scope)].(core.Dict)
+		if item == nil {
+			item = core.Dict{}
+		}
+		for verb, e := range scopes[scope] {
+			item[strings.ToLower(verb)] = operation(scope, verb, e.with, schemas)
+		}
+		paths[openAPIPath(scope)] = item
+	}
+
+	return core.Dict{
+		"openapi":    "3.1.0",
+		"info":       meta,
+		"paths":      paths,
+		"components": core.Dict{"schemas": schemas},
+	}
+}
+
+// components returns the schemas every document starts with, which
+// operations refer to.
+func components() core.Dict {
+	return core.Dict{"ValidationError": validationError}
+}
+
+var validationError = core.Dict{
+	"type":     "object",
+	"required": []string{"error", "issues"},
+	"properties": core.Dict{
+		"error": core.Dict{"type": "string"},
+		"issues": core.Dict{
+			"type": "array",
+			"items": core.Dict{
+				"type":     "object",
+				"required": []string{"path", "code", "message"},
+				"properties": core.Dict{
+					"path":    core.Dict{"type": "string"},
+					"code":    core.Dict{"type": "string"},
+					"message": core.Dict{"type": "string"},
+					"params":  core.Dict{"type": "object"},
+				},
+			},
+		},
+	},
+}
+
+// operation describes one route; the definitions its schemas share are
+// added to schemas, the document's components.
+func operation(scope, verb string, w With, schemas core.Dict) core.Dict {
+	path := openAPIPath(scope)
+	op := core.Dict{}
+	if w.Summary != "" {
+		op["summary"] = w.Summary
+	}
+	if w.Description != "" {
+		op["description"] = w.Description
+	}
+	if len(w.Tags) > 0 {
+		op["tags"] = w.Tags
+	}
+	op["operationId"] = w.OperationID
+	if w.OperationID == "" {
+		op["operationId"] = operationID(verb, path)
+	}
+
+	parameters := []core.Dict{}
+	documented := map[string]bool{}
+	for _, in := range []struct {
+		in     string
+		schema *pema.SchemaBuilder
+	}{{"path", w.Path}, {"query", w.Query}, {"header", w.Headers}} {
+		if in.schema == nil {
+			continue
+		}
+		document := in.schema.JSONSchema()
+		properties, _ := document["properties"].(core.Dict)
+		// parameters arrive as strings and are parsed with coercion
+		required := in.schema.RequiredKeys(true)
+		for _, name := range slices.Sorted(maps.Keys(properties)) {
+			parameters = append(parameters, core.Dict{
+				"name":     name,
+				"in":       in.in,
+				"required": in.in == "path" || slices.Contains(required, name),
+				"schema":   properties[name],
+			})
+			if in.in == "path" {
+				documented[name] = true
+			}
+		}
+	}
+	for _, name := range pathParameters(path) {
+		if !documented[name] {
+			parameters = append(parameters, core.Dict{
+				"name":     name,
+				"in":       "path",
+				"required": true,
+				"schema":   core.Dict{"type": "string"},
+			})
+		}
+	}
+	if len(parameters) > 0 {
+		op["parameters"] = parameters
+	}
+
+	if w.Schema != nil {
+		accepted := w.accepted()
+		if len(accepted) == 0 {
+			accepted = []ContentType{JSON}
+		}
+		content := core.Dict{}
+		for _, contentType := range accepted {
+			content[string(contentType)] = core.Dict{"schema": embed(w.Schema, schemas)}
+		}
+		op["requestBody"] = core.Dict{"required": true, "content": content}
+	}
+
+	responses := core.Dict{}
+	for status, r := range w.Responses {
+		response := core.Dict{"description": r.Description}
+		if r.Description == "" {
+			response["description"] = statusText(status)
+		}
+		if r.Schema != nil {
+			contentType := r.ContentType
+			if contentType == "" {
+				contentType = JSON
+			}
+			response["content"] = core.Dict{
+				string(contentType): core.Dict{"schema": embed(r.Schema, schemas)},
+			}
+		}
+		responses[strconv.Itoa(status)] = response
+	}
+	if len(responses) == 0 {
+		responses["200"] = core.Dict{"description": statusText(200)}
+	}
+	if w.Query != nil || w.Path != nil || w.Headers != nil || w.Schema != nil {
+		addValidationResponse(responses, 400)
+	}
+	if w.Schema != nil {
+		addValidationResponse(responses, 422)
+	}
+	if w.Schema != nil {
+		responses["413"] = core.Dict{"description": statusText(413)}
+	}
+	if len(w.accepted()) > 0 || w.Schema != nil {
+		responses["415"] = core.Dict{"description": statusText(415)}
+	}
+	op["responses"] = responses
+
+	return op
+}
+
+func addValidationResponse(responses core.Dict, status int) {
+	key := strconv.Itoa(status)
+	if _, ok := responses[key]; ok {
+		return
+	}
+	responses[key] = core.Dict{
+		"description": statusText(status),
+		"content": core.Dict{
+			string(JSON): core.Dict{
+				"schema": core.Dict{"$ref": "#/components/schemas/ValidationError"},
+			},
+		},
+	}
+}
+
+// embed returns the schema without its $schema key, which OpenAPI 3.1
+// implies, and with its $defs moved into schemas, the document's
+// components.
+func embed(schema *pema.SchemaBuilder, schemas core.Dict) core.Dict {
+	document := schema.JSONSchema()
+	delete(document, "$schema")
+	if defs, ok := document["$defs"].(core.Dict); ok {
+		delete(document, "$defs")
+		for name, def := range defs {
+			schemas[name] = def
+			rebase(def)
+		}
+		rebase(document)
+	}
+	return document
+}
+
+// rebase points the $refs into $defs below schema at the components
+// they are moved to.
+func rebase(schema any) {
+	switch s := schema.(type) {
+	case core.Dict:
+		if ref, ok := s["$ref"].(string); ok {
+			if name, ok := strings.CutPrefix(ref, "#/$defs/"); ok {
+				s["$ref"] = "#/components/schemas/" + name
+			}
+		}
+		for _, value := range s {
+			rebase(value)
+		}
+	case []core.Dict:
+		for _, item := range s {
+			rebase(item)
+		}
+	case []any:
+		for _, item := range s {
+			rebase(item)
+		}
+	}
+}
+
+// openAPIPath maps a route scope, the route file's path, onto an OpenAPI
+// path: routes/user/[id].go becomes /user/{id} and index files map onto
+// their directory.
+func openAPIPath(scope string) string {
+	scope = strings.TrimSuffix(strings.TrimPrefix(scope, "/"), ".go")
+	scope = strings.TrimPrefix(scope, "routes/")
+	var segments []string
+	for segment := range strings.SplitSeq(scope, "/") {
+		switch {
+		case segment == "" || segment == "index" || segment == "routes":
+		case parameter.MatchString(segment):
+			segments = append(segments, "{"+parameter.FindStringSubmatch(segment)[1]+"}")
+		default:
+			segments = append(segments, segment)
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+func pathParameters(path string) []string {
+	var names []string
+	for segment := range strings.SplitSeq(path, "/") {
+		if name, ok := strings.CutPrefix(segment, "{"); ok {
+			names = append(names, strings.TrimSuffix(name, "}"))
+		}
+	}
+	return names
+}
+
+func operationID(verb, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(verb))
+	upper := true
+	for _, r := range path {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9':
+			if upper {
+				b.WriteString(strings.ToUpper(string(r)))
+			} else {
+				b.WriteRune(r)
+			}
+			upper = false
+		default:
+			upper = true
+		}
+	}
+	return b.String()
+}
+
+func statusText(status int) string {
+	switch status {
+	case 200:
+		return "OK"
+	case 201:
+		return "Created"
+	case 204:
+		return "No Content"
+	case 400:
+		return "Bad Request"
+	case 401:
+		return "Unauthorized"
+	case 403:
+		return "Forbidden"
+	case 404:
+		return "Not Found"
+	case 409:
+		return "Conflict"
+	case 413:
+		return "Content Too Large"
+	case 415:
+		return "Unsupported Media Type"
+	case 422:
+		return "Unprocessable Content"
+	default:
+		return "Status " + strconv.Itoa(status)
+	}
+}
+
+func serialize(data core.Dict) string {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func toJS(values []string) []any {
+	items := make([]any, len(values))
+	for i, v := range values {
+		items[i] = v
+	}
+	return items
+}
@@ -4,11 +4,17 @@ package route
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall/js"
 
 	"github.com/primate-run/go/core"
+	"github.com/primate-run/go/pema"
+	"github.com/primate-run/go/response"
 )
 
 type Request = core.Request
@@ -23,24 +29,74 @@ const (
 	Form      ContentType = "application/x-www-form-urlencoded"
 	Multipart ContentType = "multipart/form-data"
 	Blob      ContentType = "application/octet-stream"
+	NDJSON    ContentType = "application/x-ndjson"
+	JSONSeq   ContentType = "application/json-seq"
+	XML       ContentType = "application/xml"
+	CBOR      ContentType = "application/cbor"
+	MsgPack   ContentType = "application/msgpack"
+	// Auto infers the kind from the request's Content-Type header, or
+	// from the body itself when the header is missing or unknown.
+	Auto ContentType = "*/*"
 )
 
 type With struct {
 	ContentType ContentType
+	// ContentTypes accepts several body encodings; the request's
+	// Content-Type header picks one, and others are answered with 415.
+	ContentTypes []ContentType
+	// Schema validates the body before the handler runs; the result is
+	// available as request.Valid(). Invalid bodies are answered with 422
+	// and the list of issues, undecodable ones with 400.
+	Schema *pema.SchemaBuilder
+	// Query, Path and Headers validate the respective request bags, with
+	// coercion, before the handler runs; results are available through
+	// Valid() on each bag. Failures are answered with 400.
+	Query   *pema.SchemaBuilder
+	Path    *pema.SchemaBuilder
+	Headers *pema.SchemaBuilder
+
+	// MaxBodySize, MaxPartSize and MaxFileSize override the limits set at
+	// Commit for this route; negative values lift the limit. Oversized
+	// bodies are answered with 413.
+	MaxBodySize int64
+	MaxPartSize int64
+	MaxFileSize int64
+
+	// Compression compresses responses as Accept-Encoding allows, see
+	// response.Compress; nil leaves them uncompressed.
+	Compression *response.Compression
+
+	// documentation only, see OpenAPI
+	Summary     string
+	Description string
+	OperationID string
+	Tags        []string
+	Responses   map[int]Response
 }
 
+// Response documents one status code a route may answer with.
+type Response struct {
+	Description string
+	ContentType ContentType
+	Schema      *pema.SchemaBuilder
+}
+
+type Limits = core.Limits
+
 type entry struct {
-	handler     Handler
-	contentType ContentType
+	handler Handler
+	with    With
+	limits  Limits
 }
 
 var (
-	mu      sync.Mutex
-	scopes  = map[string]map[string]entry{}
-	pending = []struct {
-		verb        string
-		handler     Handler
-		contentType ContentType
+	mu       sync.Mutex
+	defaults = Limits{Body: 32 << 20}
+	scopes   = map[string]map[string]entry{}
+	pending  = []struct {
+		verb    string
+		handler Handler
+		with    With
 	}{}
 )
 
@@ -49,10 +105,10 @@ func register(verb string, h Handler, w With) Handler {
 	defer mu.Unlock()
 
 	pending = append(pending, struct {
-		verb        string
-		handler     Handler
-		contentType ContentType
-	}{verb, h, w.ContentType})
+		verb    string
+		handler Handler
+		with    With
+	}{verb, h, w})
 
 	return h
 }
@@ -100,15 +156,80 @@ func makeURL(request js.Value) core.URL {
 	}
 }
 
-func makeRequest(request js.Value, contentType string) core.Request {
-	return core.Request{
+func makeRequest(request js.Value, w With, limits Limits) (core.Request, error) {
+	req := core.Request{
 		Url:     makeURL(request),
-		Body:    core.NewBodyFromJS(request.Get("body"), contentType),
 		Path:    makeRequestBag(request.Get("path").String(), "path"),
 		Query:   makeRequestBag(request.Get("query").String(), "query"),
 		Headers: makeRequestBag(request.Get("headers").String(), "headers"),
 		Cookies: makeRequestBag(request.Get("cookies").String(), "cookies"),
 	}
+	// per RFC 9112, a request has a body when it declares its framing
+	length, _ := strconv.ParseInt(req.Header("content-length"), 10, 64)
+	hasBody := length > 0 || req.Header("transfer-encoding") != ""
+	contentType, err := w.resolve(req.Header("content-type"), hasBody)
+	if err != nil {
+		return core.Request{}, err
+	}
+
+	req.Body = core.NewBodyFromJS(request.Get("body"), string(contentType), limits)
+	if err := req.Body.SetContentEncoding(req.Header("content-encoding")); err != nil {
+		return core.Request{}, err
+	}
+	return req, nil
+}
+
+func (w With) limits(defaults Limits) Limits {
+	if w.MaxBodySize != 0 {
+		defaults.Body = w.MaxBodySize
+	}
+	if w.MaxPartSize != 0 {
+		defaults.Part = w.MaxPartSize
+	}
+	if w.MaxFileSize != 0 {
+		defaults.File = w.MaxFileSize
+	}
+	return defaults
+}
+
+func (w With) accepted() []ContentType {
+	accepted := slices.Clone(w.ContentTypes)
+	if w.ContentType != "" && !slices.Contains(accepted, w.ContentType) {
+		accepted = append([]ContentType{w.ContentType}, accepted...)
+	}
+	return accepted
+}
+
+// resolve picks the declared content type matching the request's
+// Content-Type header. A request without a body is never rejected, and
+// one without the header is taken to be of the single declared type.
+func (w With) resolve(header string, hasBody bool) (ContentType, error) {
+	accepted := w.accepted()
+	kind := core.KindOf(header)
+	if slices.Contains(accepted, Auto) {
+		if kind != core.KindNone {
+			return ContentType(header), nil
+		}
+		return Auto, nil
+	}
+	// the header is passed on when it matches, for its parameters (charset)
+	for _, contentType := range accepted {
+		if kind != core.KindNone && core.KindOf(string(contentType)) == kind {
+			return ContentType(header), nil
+		}
+	}
+	switch {
+	case len(accepted) == 0:
+		return "", nil
+	case !hasBody || header == "" && len(accepted) == 1:
+		return accepted[0], nil
+	}
+	names := make([]string, len(accepted))
+	for i, contentType := range accepted {
+		names[i] = string(contentType)
+	}
+	return "", fmt.Errorf("unsupported content type %q, expected one of %s",
+		header, strings.Join(names, ", "))
 }
 
 func makeRequestBag(jsonStr, name string) *core.RequestBag {
@@ -127,34 +248,121 @@ func CallJS(scope_id, verb string, request js.Value) any {
 		return `{"error":"no scope ` + scope_id + `"}`
 	}
 	e, ok := registry[verb]
+	limits := e.with.limits(e.limits)
 	mu.Unlock()
 
 	if !ok {
 		return `{"error":"no handler for ` + verb + ` in scope ` + scope_id + `"}`
 	}
 
-	response := e.handler(makeRequest(request, string(e.contentType)))
+	req, err := makeRequest(request, e.with, limits)
+	if err != nil {
+		return send(response.JSON(core.Dict{"error": err.Error()}, 415))
+	}
+	if length, err := strconv.ParseInt(req.Header("content-length"), 10, 64); err == nil &&
+		limits.Body > 0 && length > limits.Body {
+		return send(reject(&core.TooLargeError{What: "body", Limit: limits.Body, Size: length}))
+	}
+	if rejected := validate(req, e.with); rejected != nil {
+		return send(rejected)
+	}
 
-	if fn, ok := response.(js.Func); ok {
+	result := e.handler(req)
+	if e.with.Compression != nil {
+		result = response.Compress(req, result, *e.with.Compression)
+	}
+	return send(result)
+}
+
+// validate runs the schemas declared in w against the request, returning
+// the response to send instead of calling the handler when one fails.
+func validate(req core.Request, w With) any {
+	bags := []struct {
+		name   string
+		bag    *core.RequestBag
+		schema *pema.SchemaBuilder
+	}{
+		{"path", req.Path, w.Path},
+		{"query", req.Query, w.Query},
+		{"headers", req.Headers, w.Headers},
+	}
+	var issues []pema.Issue
+	for _, b := range bags {
+		if b.schema == nil {
+			continue
+		}
+		if _, err := b.bag.Validate(b.schema); err != nil {
+			for _, issue := range pema.Issues(err) {
+				issue.Path = join(b.name, issue.Path)
+				issues = append(issues, issue)
+			}
+		}
+	}
+	if issues != nil {
+		return response.JSON(core.Dict{
+			"error":  "invalid request",
+			"issues": issues,
+		}, 400)
+	}
+
+	if w.Schema != nil {
+		if _, err := req.Body.Validate(w.Schema); err != nil {
+			return reject(err)
+		}
+	}
+	return nil
+}
+
+func join(prefix, path string) string {
+	if path == "" {
+		return prefix
+	}
+	return prefix + "." + path
+}
+
+func send(result any) any {
+	if fn, ok := result.(js.Func); ok {
 		return fn.Invoke()
 	}
 
-	b, _ := json.Marshal(response)
+	b, _ := json.Marshal(result)
 	return string(b)
 }
 
-func Commit(scope_id string) {
+func reject(err error) any {
+	if errors.Is(err, core.ErrTooLarge) {
+		return response.JSON(core.Dict{"error": err.Error()}, 413)
+	}
+	var parseErr *pema.ParseError
+	if errors.As(err, &parseErr) {
+		return response.JSON(core.Dict{
+			"error":  "validation failed",
+			"issues": parseErr.Issues,
+		}, 422)
+	}
+	return response.JSON(core.Dict{"error": err.Error()}, 400)
+}
+
+// Commit registers the pending routes under scope_id. limits, if given,
+// replaces the default body limits (32 MiB per body) for these routes.
+func Commit(scope_id string, limits ...Limits) {
 	mu.Lock()
 	defer mu.Unlock()
 
+	scopeLimits := defaults
+	if len(limits) > 0 {
+		scopeLimits = limits[0]
+	}
+
 	if scopes[scope_id] == nil {
 		scopes[scope_id] = make(map[string]entry)
 	}
 
 	for _, p := range pending {
 		scopes[scope_id][p.verb] = entry{
-			handler:     p.handler,
-			contentType: p.contentType,
+			handler: p.handler,
+			with:    p.with,
+			limits:  scopeLimits,
 		}
 	}
 	pending = nil
@@ -179,7 +387,23 @@ func Commit(scope_id string) {
 		for verb, e := range registry {
 			obj := js.Global().Get("Object").New()
 			obj.Set("verb", verb)
-			obj.Set("contentType", string(e.contentType))
+			accepted := e.with.accepted()
+			contentTypes := make([]any, len(accepted))
+			for i, contentType := range accepted {
+				contentTypes[i] = string(contentType)
+			}
+			if len(accepted) > 0 {
+				obj.Set("contentType", contentTypes[0])
+			} else {
+				obj.Set("contentType", "")
+			}
+			obj.Set("contentTypes", contentTypes)
+			obj.Set("summary", e.with.Summary)
+			obj.Set("description", e.with.Description)
+			obj.Set("tags", toJS(e.with.Tags))
+			schemas := components()
+			obj.Set("operation", serialize(operation(scope_id, verb, e.with, schemas)))
+			obj.Set("schemas", serialize(schemas))
 			arr.SetIndex(i, obj)
 			i++
 		}
@@ -5,3 +5,11 @@ package types
 type Object[T any] map[string]T
 type Array[T any] []T
 type Dict = Object[any]
+
+type UploadFile struct {
+	Field string
+	Name  string
+	Type  string
+	Size  int64
+	Bytes []byte
+}